@@ -0,0 +1,242 @@
+package logevent
+
+import (
+	"strings"
+	"sync"
+	"sync/atomic"
+
+	"github.com/rs/zerolog"
+)
+
+// Overflow policies that may be selected with AsyncConfig.Overflow.
+const (
+	// OverflowBlock waits for space in the queue. This is the default.
+	OverflowBlock = "block"
+	// OverflowDropNewest discards the event being logged.
+	OverflowDropNewest = "drop_newest"
+	// OverflowDropOldest discards the oldest queued event to make space.
+	OverflowDropOldest = "drop_oldest"
+	// OverflowDropBelowLevel discards the event being logged if it is below
+	// AsyncConfig.MinLevel and otherwise waits for space in the queue.
+	OverflowDropBelowLevel = "drop_below_level"
+)
+
+const defaultAsyncSize = 1024
+
+// AsyncConfig records the settings for asynchronous emission.
+type AsyncConfig struct {
+	// Enabled toggles asynchronous emission. Events are queued and written
+	// to the output by a background goroutine.
+	Enabled bool `yaml:"enabled"`
+	// Size is the maximum number of queued events. Defaults to 1024.
+	Size int `yaml:"size"`
+	// Overflow is the policy applied when the queue is full. Defaults to
+	// OverflowBlock.
+	Overflow string `yaml:"overflow"`
+	// MinLevel is the lowest level that is never dropped when Overflow is
+	// OverflowDropBelowLevel.
+	MinLevel string `yaml:"min_level"`
+}
+
+// Stats records the state of a Logger's asynchronous queue.
+type Stats struct {
+	// Queued is the number of events waiting to be written.
+	Queued int
+	// Dropped is the number of events discarded by the overflow policy.
+	Dropped uint64
+}
+
+type asyncBackend struct {
+	next     backend
+	overflow string
+	minLevel zerolog.Level
+	dropped  uint64
+
+	lock    sync.Mutex
+	changed *sync.Cond
+	queue   []*record
+	head    int
+	count   int
+	writing bool
+	closed  bool
+	done    chan struct{}
+	once    sync.Once
+}
+
+func newAsyncBackend(next backend, c AsyncConfig) *asyncBackend {
+	var size = c.Size
+	if size < 1 {
+		size = defaultAsyncSize
+	}
+	var b = &asyncBackend{
+		next:     next,
+		overflow: strings.ToLower(c.Overflow),
+		minLevel: levelFromString(c.MinLevel),
+		queue:    make([]*record, size),
+		done:     make(chan struct{}),
+	}
+	b.changed = sync.NewCond(&b.lock)
+	go b.run()
+	return b
+}
+
+func (b *asyncBackend) write(r *record) {
+	b.lock.Lock()
+	for {
+		if b.closed {
+			b.lock.Unlock()
+			// events logged after Close are written synchronously rather
+			// than being lost
+			b.next.write(r)
+			return
+		}
+		if b.count < len(b.queue) {
+			break
+		}
+		if !b.overflowWait(r) {
+			b.lock.Unlock()
+			return
+		}
+	}
+	b.queue[(b.head+b.count)%len(b.queue)] = r
+	b.count = b.count + 1
+	b.changed.Broadcast()
+	b.lock.Unlock()
+}
+
+// overflowWait applies the overflow policy to a full queue. It returns
+// false if the record must be dropped. The lock must be held.
+func (b *asyncBackend) overflowWait(r *record) bool {
+	switch b.overflow {
+	case OverflowDropNewest:
+		atomic.AddUint64(&b.dropped, 1)
+		return false
+	case OverflowDropOldest:
+		b.queue[b.head] = nil
+		b.head = (b.head + 1) % len(b.queue)
+		b.count = b.count - 1
+		atomic.AddUint64(&b.dropped, 1)
+		return true
+	case OverflowDropBelowLevel:
+		if r.level < b.minLevel {
+			atomic.AddUint64(&b.dropped, 1)
+			return false
+		}
+	}
+	b.changed.Wait()
+	return true
+}
+
+// run writes queued records until the backend is closed and drained.
+func (b *asyncBackend) run() {
+	defer close(b.done)
+	var batch = make([]*record, 0, len(b.queue))
+	for {
+		b.lock.Lock()
+		for b.count == 0 && !b.closed {
+			b.changed.Wait()
+		}
+		if b.count == 0 {
+			b.lock.Unlock()
+			return
+		}
+		for ; b.count > 0; b.count = b.count - 1 {
+			batch = append(batch, b.queue[b.head])
+			b.queue[b.head] = nil
+			b.head = (b.head + 1) % len(b.queue)
+		}
+		b.writing = true
+		b.changed.Broadcast()
+		b.lock.Unlock()
+
+		for _, r := range batch {
+			b.next.write(r)
+		}
+		batch = batch[:0]
+
+		b.lock.Lock()
+		b.writing = false
+		b.changed.Broadcast()
+		b.lock.Unlock()
+	}
+}
+
+// flush blocks until every queued record has been written.
+func (b *asyncBackend) flush() {
+	b.lock.Lock()
+	for (b.count > 0 || b.writing) && !b.closed {
+		b.changed.Wait()
+	}
+	b.lock.Unlock()
+	if b.isClosed() {
+		<-b.done
+	}
+}
+
+// close drains the queue and stops the background goroutine.
+func (b *asyncBackend) close() {
+	b.once.Do(func() {
+		b.lock.Lock()
+		b.closed = true
+		b.changed.Broadcast()
+		b.lock.Unlock()
+	})
+	<-b.done
+}
+
+func (b *asyncBackend) isClosed() bool {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+	return b.closed
+}
+
+func (b *asyncBackend) stats() Stats {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+	return Stats{Queued: b.count, Dropped: atomic.LoadUint64(&b.dropped)}
+}
+
+func (log *logger) flush() {
+	if b, ok := log.backend.(*asyncBackend); ok {
+		b.flush()
+	}
+}
+
+func (log *logger) close() {
+	if b, ok := log.backend.(*asyncBackend); ok {
+		b.close()
+	}
+}
+
+func (log *logger) stats() Stats {
+	if b, ok := log.backend.(*asyncBackend); ok {
+		return b.stats()
+	}
+	return Stats{}
+}
+
+// Flush blocks until every event logged before the call has been written.
+// It has no effect on Loggers that do not buffer events.
+func Flush(logger Logger) {
+	if f, ok := logger.(interface{ flush() }); ok {
+		f.flush()
+	}
+}
+
+// Close flushes a Logger and stops any background goroutines that it
+// uses. Events logged after Close are written synchronously. Close should
+// be called once when the application shuts down.
+func Close(logger Logger) {
+	if c, ok := logger.(interface{ close() }); ok {
+		c.close()
+	}
+}
+
+// GetStats returns the state of a Logger's asynchronous queue. The zero
+// value is returned for Loggers that do not buffer events.
+func GetStats(logger Logger) Stats {
+	if s, ok := logger.(interface{ stats() Stats }); ok {
+		return s.stats()
+	}
+	return Stats{}
+}
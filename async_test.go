@@ -0,0 +1,128 @@
+package logevent
+
+import (
+	"bytes"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/require"
+)
+
+// gatedBackend records messages and blocks every write until released.
+type gatedBackend struct {
+	lock     sync.Mutex
+	messages []string
+	started  chan struct{}
+	release  chan struct{}
+}
+
+func newGatedBackend() *gatedBackend {
+	return &gatedBackend{started: make(chan struct{}, 1), release: make(chan struct{})}
+}
+
+func (b *gatedBackend) write(r *record) {
+	select {
+	case b.started <- struct{}{}:
+	default:
+	}
+	<-b.release
+	b.lock.Lock()
+	defer b.lock.Unlock()
+	b.messages = append(b.messages, r.message)
+}
+
+func (b *gatedBackend) written() []string {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+	return append([]string(nil), b.messages...)
+}
+
+// fillQueue writes a record that blocks the background goroutine and then
+// fills the queue behind it.
+func fillQueue(t *testing.T, b *asyncBackend, gate *gatedBackend, messages ...string) {
+	b.write(&record{level: zerolog.InfoLevel, message: "blocking"})
+	<-gate.started
+	for _, message := range messages {
+		b.write(&record{level: zerolog.InfoLevel, message: message})
+	}
+	require.Equal(t, len(messages), b.stats().Queued)
+}
+
+func TestAsyncOrderAndFlush(t *testing.T) {
+	var buff = &bytes.Buffer{}
+	var logger = New(Config{Output: buff, Async: AsyncConfig{Enabled: true, Size: 2}})
+	defer Close(logger)
+	for _, message := range []string{"one", "two", "three", "four"} {
+		logger.Info(message)
+	}
+	Flush(logger)
+	var output = buff.String()
+	require.Equal(t, 4, strings.Count(output, "\n"))
+	require.True(t, strings.Index(output, "one") < strings.Index(output, "four"))
+	require.Equal(t, Stats{}, GetStats(logger))
+}
+
+func TestAsyncDropNewest(t *testing.T) {
+	var gate = newGatedBackend()
+	var b = newAsyncBackend(gate, AsyncConfig{Size: 2, Overflow: OverflowDropNewest})
+	fillQueue(t, b, gate, "one", "two")
+	b.write(&record{level: zerolog.ErrorLevel, message: "three"})
+	require.Equal(t, Stats{Queued: 2, Dropped: 1}, b.stats())
+	close(gate.release)
+	b.close()
+	require.Equal(t, []string{"blocking", "one", "two"}, gate.written())
+}
+
+func TestAsyncDropOldest(t *testing.T) {
+	var gate = newGatedBackend()
+	var b = newAsyncBackend(gate, AsyncConfig{Size: 2, Overflow: OverflowDropOldest})
+	fillQueue(t, b, gate, "one", "two")
+	b.write(&record{level: zerolog.InfoLevel, message: "three"})
+	require.Equal(t, Stats{Queued: 2, Dropped: 1}, b.stats())
+	close(gate.release)
+	b.close()
+	require.Equal(t, []string{"blocking", "two", "three"}, gate.written())
+}
+
+func TestAsyncDropBelowLevel(t *testing.T) {
+	var gate = newGatedBackend()
+	var b = newAsyncBackend(gate, AsyncConfig{Size: 1, Overflow: OverflowDropBelowLevel, MinLevel: "WARN"})
+	fillQueue(t, b, gate, "one")
+	b.write(&record{level: zerolog.InfoLevel, message: "dropped"})
+	require.Equal(t, Stats{Queued: 1, Dropped: 1}, b.stats())
+
+	var done = make(chan struct{})
+	go func() {
+		b.write(&record{level: zerolog.ErrorLevel, message: "kept"})
+		close(done)
+	}()
+	close(gate.release)
+	<-done
+	b.close()
+	require.Equal(t, []string{"blocking", "one", "kept"}, gate.written())
+}
+
+func TestAsyncClose(t *testing.T) {
+	var buff = &bytes.Buffer{}
+	var logger = New(Config{Output: buff, Async: AsyncConfig{Enabled: true}})
+	logger.Info("before close")
+	Close(logger)
+	require.Contains(t, buff.String(), "before close")
+	Close(logger)
+	logger.Copy().Info("after close")
+	require.Contains(t, buff.String(), "after close")
+	Flush(logger)
+}
+
+func TestAsyncNotEnabled(t *testing.T) {
+	var buff = &bytes.Buffer{}
+	var logger = New(Config{Output: buff})
+	logger.Info("sync")
+	Flush(logger)
+	Close(logger)
+	require.Contains(t, buff.String(), "sync")
+	require.Equal(t, Stats{}, GetStats(logger))
+	require.Equal(t, Stats{}, GetStats(NewNop()))
+}
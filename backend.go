@@ -0,0 +1,67 @@
+package logevent
+
+import (
+	"runtime"
+	"strconv"
+	"time"
+
+	"github.com/rs/zerolog"
+)
+
+const callerKey = "file"
+
+// record is a fully rendered event that is ready to be written by a
+// backend. Backends omit the timestamp if the time is zero.
+type record struct {
+	time    time.Time
+	level   zerolog.Level
+	message string
+	pc      uintptr
+	fields  map[string]interface{}
+}
+
+// frame resolves the source location of the log call. The File of the
+// frame is empty if the location is unknown.
+func (r *record) frame() runtime.Frame {
+	if r.pc == 0 {
+		return runtime.Frame{}
+	}
+	var frames = runtime.CallersFrames([]uintptr{r.pc})
+	var frame, _ = frames.Next()
+	return frame
+}
+
+// caller renders the source location of the log call in the file:line
+// format. An empty string is returned if the location is unknown.
+func (r *record) caller() string {
+	var frame = r.frame()
+	if frame.File == "" {
+		return ""
+	}
+	return frame.File + ":" + strconv.Itoa(frame.Line)
+}
+
+// backend is the final destination of a rendered event. Level filtering
+// happens before a record reaches a backend so implementations write
+// everything they are given.
+type backend interface {
+	write(r *record)
+}
+
+type zerologBackend struct {
+	l zerolog.Logger
+}
+
+func (b *zerologBackend) write(r *record) {
+	var e = b.l.WithLevel(r.level)
+	if e == nil {
+		return
+	}
+	if !r.time.IsZero() {
+		e = e.Time(zerolog.TimestampFieldName, r.time)
+	}
+	if caller := r.caller(); caller != "" {
+		e = e.Str(callerKey, caller)
+	}
+	e.Fields(r.fields).Msg(r.message)
+}
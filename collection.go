@@ -0,0 +1,136 @@
+package logevent
+
+import (
+	"fmt"
+	"reflect"
+	"sort"
+)
+
+// DefaultMaxElements is the number of elements of a collection of structs
+// that are rendered when Config.MaxElements is not set.
+const DefaultMaxElements = 100
+
+// omittedSuffix is added to the name of a truncated collection to name the
+// count of elements that were not rendered.
+const omittedSuffix = "_omitted"
+
+// collectionValue resolves an interface to a slice, array, or map whose
+// elements are structs, or pointers to structs, with exported fields, or
+// that choose their own representation. A collection with elements of
+// interface type is accepted if at least one element holds such a value,
+// and each element is then rendered according to the value that it holds.
+// The second return is false for any other value, including nil
+// collections.
+func collectionValue(v reflect.Value) (reflect.Value, bool) {
+	if v.Kind() == reflect.Interface {
+		v = v.Elem()
+	}
+	switch v.Kind() {
+	case reflect.Slice, reflect.Map:
+		if v.IsNil() {
+			return v, false
+		}
+	case reflect.Array:
+	default:
+		return v, false
+	}
+	var elem = v.Type().Elem()
+	if elem.Kind() != reflect.Interface {
+		return v, rendersElements(elem)
+	}
+	if v.Kind() == reflect.Map {
+		var iter = v.MapRange()
+		for iter.Next() {
+			if holdsRenderable(iter.Value()) {
+				return v, true
+			}
+		}
+		return v, false
+	}
+	for x := 0; x < v.Len(); x = x + 1 {
+		if holdsRenderable(v.Index(x)) {
+			return v, true
+		}
+	}
+	return v, false
+}
+
+// rendersElements reports whether elements of the type are rendered with
+// the same tag rules as an event, rather than passed through as they are.
+func rendersElements(t reflect.Type) bool {
+	if marshals(t) {
+		return true
+	}
+	if t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	return t.Kind() == reflect.Struct && len(schemaOf(t).fields) > 0
+}
+
+// holdsRenderable reports whether an element of interface type holds a
+// value that rendersElements accepts.
+func holdsRenderable(v reflect.Value) bool {
+	return !v.IsNil() && rendersElements(v.Elem().Type())
+}
+
+// elementLimit returns the number of elements of a collection of the given
+// length that are rendered.
+func (r *renderer) elementLimit(length int) int {
+	var limit = r.maxElements
+	if limit == 0 {
+		limit = DefaultMaxElements
+	}
+	if limit < 0 || limit > length {
+		return length
+	}
+	return limit
+}
+
+// renderCollection renders each element of a collection with the same tag
+// rules as an event. Slices and arrays become lists and maps become
+// objects, with keys formatted by fmt. Maps are truncated in key order.
+// The second return is the number of elements that were not rendered.
+func (p *renderPass) renderCollection(v reflect.Value, depth int) (interface{}, int) {
+	var limit = p.elementLimit(v.Len())
+	if v.Kind() != reflect.Map {
+		var rendered = make([]interface{}, limit)
+		for x := range rendered {
+			rendered[x] = p.renderElement(v.Index(x), depth)
+		}
+		return rendered, v.Len() - limit
+	}
+	var keys = make([]string, 0, v.Len())
+	var values = make(map[string]reflect.Value, v.Len())
+	var iter = v.MapRange()
+	for iter.Next() {
+		var key = fmt.Sprint(iter.Key().Interface())
+		keys = append(keys, key)
+		values[key] = iter.Value()
+	}
+	sort.Strings(keys)
+	var rendered = make(map[string]interface{}, limit)
+	for _, key := range keys[:limit] {
+		rendered[key] = p.renderElement(values[key], depth)
+	}
+	return rendered, len(keys) - limit
+}
+
+func (p *renderPass) renderElement(v reflect.Value, depth int) interface{} {
+	if marshaled, ok := marshalValue(v); ok {
+		if marshaled == nil {
+			return nil
+		}
+		v = reflect.ValueOf(marshaled)
+	}
+	if text, ok := textualValue(v); ok {
+		return text
+	}
+	var element, seen, ok = resolve(v)
+	if ok && len(schemaOf(element.Type()).fields) > 0 {
+		return p.nested(element, seen, depth)
+	}
+	if (element.Kind() == reflect.Ptr || element.Kind() == reflect.Interface) && element.IsNil() {
+		return nil
+	}
+	return element.Interface()
+}
@@ -0,0 +1,118 @@
+package logevent
+
+import (
+	"bytes"
+	"fmt"
+	"reflect"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+type attempt struct {
+	Status  int    `logevent:"status"`
+	Reason  string `logevent:"reason,default=none"`
+	Message string `logevent:"message,default=attempt"`
+}
+
+type eventWithCollections struct {
+	Attempts []attempt          `logevent:"attempts"`
+	Pointers []*attempt         `logevent:"pointers"`
+	Fixed    [2]attempt         `logevent:"fixed"`
+	Targets  map[string]attempt `logevent:"targets"`
+	Empty    []attempt          `logevent:"empty"`
+	Names    []string           `logevent:"names"`
+	Message  string             `logevent:"message,default=collections"`
+}
+
+func TestRenderCollections(t *testing.T) {
+	var _, annotations = Render(eventWithCollections{
+		Attempts: []attempt{{Status: 500}, {Status: 200, Reason: "retried"}},
+		Pointers: []*attempt{{Status: 404}, nil},
+		Targets:  map[string]attempt{"b": {Status: 2}, "a": {Status: 1}},
+		Names:    []string{"x"},
+	})
+	require.Equal(t, []interface{}{
+		map[string]interface{}{"status": 500, "reason": "none", "message": "attempt"},
+		map[string]interface{}{"status": 200, "reason": "retried", "message": "attempt"},
+	}, annotations["attempts"])
+	require.Equal(t, []interface{}{
+		map[string]interface{}{"status": 404, "reason": "none", "message": "attempt"},
+		nil,
+	}, annotations["pointers"])
+	require.Len(t, annotations["fixed"], 2)
+	require.Equal(t, map[string]interface{}{
+		"a": map[string]interface{}{"status": 1, "reason": "none", "message": "attempt"},
+		"b": map[string]interface{}{"status": 2, "reason": "none", "message": "attempt"},
+	}, annotations["targets"])
+	require.Nil(t, annotations["empty"])
+	require.Equal(t, []string{"x"}, annotations["names"])
+	require.NotContains(t, annotations, "attempts"+omittedSuffix)
+}
+
+func TestRenderCollectionsTruncated(t *testing.T) {
+	var buff = &bytes.Buffer{}
+	var logger = New(Config{Output: buff, MaxElements: 1})
+	logger.Info(eventWithCollections{
+		Attempts: []attempt{{Status: 1}, {Status: 2}, {Status: 3}},
+		Targets:  map[string]attempt{"b": {Status: 2}, "a": {Status: 1}},
+	})
+	var line = lastLine(t, buff)
+	var attempts = line["attempts"].([]interface{})
+	require.Len(t, attempts, 1)
+	require.Equal(t, 1.0, attempts[0].(map[string]interface{})["status"])
+	require.Equal(t, 2.0, line["attempts"+omittedSuffix])
+	require.Contains(t, line["targets"], "a")
+	require.NotContains(t, line["targets"], "b")
+	require.Equal(t, 1.0, line["targets"+omittedSuffix])
+	require.Equal(t, 1.0, line["fixed"+omittedSuffix])
+
+	var large = reflect.ValueOf(make([]attempt, DefaultMaxElements+1))
+	var p = &renderPass{renderer: &renderer{maxElements: -1}}
+	var rendered, omitted = p.renderCollection(large, 0)
+	require.Len(t, rendered, DefaultMaxElements+1)
+	require.Zero(t, omitted)
+	p = &renderPass{renderer: &renderer{}}
+	rendered, omitted = p.renderCollection(large, 0)
+	require.Len(t, rendered, DefaultMaxElements)
+	require.Equal(t, 1, omitted)
+}
+
+type eventWithInterfaces struct {
+	Items   []interface{}          `logevent:"items"`
+	Details map[string]interface{} `logevent:"details"`
+	Message string                 `logevent:"message,default=interfaces"`
+}
+
+func TestRenderCollectionsOfInterfaces(t *testing.T) {
+	var _, annotations = Render(eventWithInterfaces{
+		Items:   []interface{}{attempt{Status: 1}, &attempt{Status: 2}, "text", 3, nil, prefix{Bits: 8}},
+		Details: map[string]interface{}{"attempt": attempt{Status: 4}, "count": 5},
+	})
+	require.Equal(t, []interface{}{
+		map[string]interface{}{"status": 1, "reason": "none", "message": "attempt"},
+		map[string]interface{}{"status": 2, "reason": "none", "message": "attempt"},
+		"text",
+		3,
+		nil,
+		"10.0.0.0/8",
+	}, annotations["items"])
+	require.Equal(t, map[string]interface{}{
+		"attempt": map[string]interface{}{"status": 4, "reason": "none", "message": "attempt"},
+		"count":   5,
+	}, annotations["details"])
+}
+
+func TestRenderCollectionsOfScalarInterfaces(t *testing.T) {
+	var details = make(map[string]interface{}, 150)
+	var items = make([]interface{}, 150)
+	for x := 0; x < 150; x = x + 1 {
+		details[fmt.Sprint("key", x)] = x
+		items[x] = x
+	}
+	var _, annotations = Render(eventWithInterfaces{Items: items, Details: details})
+	require.Equal(t, details, annotations["details"])
+	require.Equal(t, items, annotations["items"])
+	require.NotContains(t, annotations, "details"+omittedSuffix)
+	require.NotContains(t, annotations, "items"+omittedSuffix)
+}
@@ -0,0 +1,304 @@
+package logevent
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Output destinations that may be selected with FileConfig.Output. Any
+// other value is the path of a file to which logs are appended.
+const (
+	OutputStdout = "stdout"
+	OutputStderr = "stderr"
+)
+
+// FileConfig is the form of Config that is read from a JSON or YAML file or
+// from environment variables. Use Config to convert it for use with New.
+type FileConfig struct {
+	Level            string                 `yaml:"level"`
+	Levels           map[string]string      `yaml:"levels"`
+	Encoder          string                 `yaml:"encoder"`
+	HumanReadable    bool                   `yaml:"human_readable"`
+	Output           string                 `yaml:"output"`
+	Rotation         *RotationConfig        `yaml:"rotation"`
+	Fields           map[string]interface{} `yaml:"fields"`
+	DisableCaller    bool                   `yaml:"disable_caller"`
+	DisableTimestamp bool                   `yaml:"disable_timestamp"`
+	ErrorStack       bool                   `yaml:"error_stack"`
+	RedactFields     []string               `yaml:"redact_fields"`
+	HashKey          string                 `yaml:"hash_key"`
+	MaxElements      int                    `yaml:"max_elements"`
+	MaxDepth         int                    `yaml:"max_depth"`
+	Async            AsyncConfig            `yaml:"async"`
+	Sampling         SamplingConfig         `yaml:"sampling"`
+}
+
+// LoadConfig reads a Config from a JSON or YAML file. Unknown keys and
+// invalid values are reported as errors.
+func LoadConfig(path string) (Config, error) {
+	var data, err = os.ReadFile(path)
+	if err != nil {
+		return Config{}, err
+	}
+	c, err := ParseConfig(data)
+	if err != nil {
+		return Config{}, fmt.Errorf("%s: %w", path, err)
+	}
+	return c, nil
+}
+
+// ParseConfig reads a Config from a JSON or YAML document. Unknown keys and
+// invalid values are reported as errors.
+func ParseConfig(data []byte) (Config, error) {
+	var fc FileConfig
+	if err := decodeStrict(data, &fc); err != nil {
+		return Config{}, err
+	}
+	return fc.Config()
+}
+
+// ConfigFromEnv reads a Config from environment variables named with the
+// given prefix, such as LOGEVENT_LEVEL for the prefix LOGEVENT_. The
+// variables are LEVEL, LEVELS, ENCODER, HUMAN_READABLE, OUTPUT, FIELDS,
+// DISABLE_CALLER, DISABLE_TIMESTAMP, ERROR_STACK, REDACT_FIELDS, HASH_KEY,
+// MAX_ELEMENTS, MAX_DEPTH, ASYNC, and SAMPLING. LEVELS and FIELDS are comma
+// separated lists of name=value pairs, REDACT_FIELDS is a comma separated
+// list, and ASYNC and SAMPLING are JSON or YAML documents.
+func ConfigFromEnv(prefix string) (Config, error) {
+	var fc = FileConfig{
+		Level:        os.Getenv(prefix + "LEVEL"),
+		Encoder:      os.Getenv(prefix + "ENCODER"),
+		Output:       os.Getenv(prefix + "OUTPUT"),
+		RedactFields: splitList(os.Getenv(prefix + "REDACT_FIELDS")),
+		HashKey:      os.Getenv(prefix + "HASH_KEY"),
+	}
+	if err := validateLevel(prefix+"LEVEL", fc.Level); err != nil {
+		return Config{}, err
+	}
+	if err := validateEncoder(prefix+"ENCODER", fc.Encoder); err != nil {
+		return Config{}, err
+	}
+	var err error
+	if fc.Levels, err = ParseLevels(os.Getenv(prefix + "LEVELS")); err != nil {
+		return Config{}, fmt.Errorf("%sLEVELS: %w", prefix, err)
+	}
+	if fc.Fields, err = parseFields(os.Getenv(prefix + "FIELDS")); err != nil {
+		return Config{}, fmt.Errorf("%sFIELDS: %w", prefix, err)
+	}
+	var integers = []struct {
+		name  string
+		value *int
+	}{
+		{"MAX_ELEMENTS", &fc.MaxElements},
+		{"MAX_DEPTH", &fc.MaxDepth},
+	}
+	for _, integer := range integers {
+		var value = os.Getenv(prefix + integer.name)
+		if value == "" {
+			continue
+		}
+		if *integer.value, err = strconv.Atoi(value); err != nil {
+			return Config{}, fmt.Errorf("%s%s: invalid integer %q", prefix, integer.name, value)
+		}
+	}
+	var flags = []struct {
+		name  string
+		value *bool
+	}{
+		{"HUMAN_READABLE", &fc.HumanReadable},
+		{"DISABLE_CALLER", &fc.DisableCaller},
+		{"DISABLE_TIMESTAMP", &fc.DisableTimestamp},
+		{"ERROR_STACK", &fc.ErrorStack},
+	}
+	for _, flag := range flags {
+		var value = os.Getenv(prefix + flag.name)
+		if value == "" {
+			continue
+		}
+		if *flag.value, err = strconv.ParseBool(value); err != nil {
+			return Config{}, fmt.Errorf("%s%s: invalid boolean %q", prefix, flag.name, value)
+		}
+	}
+	var documents = []struct {
+		name  string
+		value interface{}
+	}{
+		{"ASYNC", &fc.Async},
+		{"SAMPLING", &fc.Sampling},
+	}
+	for _, document := range documents {
+		var value = os.Getenv(prefix + document.name)
+		if value == "" {
+			continue
+		}
+		if err = decodeStrict([]byte(value), document.value); err != nil {
+			return Config{}, fmt.Errorf("%s%s: %w", prefix, document.name, err)
+		}
+	}
+	return fc.Config()
+}
+
+// Config validates the settings and converts them in to a Config. A file
+// named by Output is opened for appending, as a RotatingFile if Rotation is
+// set, and remains open for the life of the application. The file is only
+// opened once every other setting is valid, so nothing is left open when
+// an error is returned.
+func (fc FileConfig) Config() (Config, error) {
+	if err := fc.validate(); err != nil {
+		return Config{}, err
+	}
+	var c = Config{
+		Level:            fc.Level,
+		Levels:           fc.Levels,
+		Encoder:          fc.Encoder,
+		HumanReadable:    fc.HumanReadable,
+		Fields:           fc.Fields,
+		DisableCaller:    fc.DisableCaller,
+		DisableTimestamp: fc.DisableTimestamp,
+		ErrorStack:       fc.ErrorStack,
+		RedactFields:     fc.RedactFields,
+		HashKey:          fc.HashKey,
+		MaxElements:      fc.MaxElements,
+		MaxDepth:         fc.MaxDepth,
+		Async:            fc.Async,
+		Sampling:         fc.Sampling,
+	}
+	if err := c.Validate(); err != nil {
+		return Config{}, err
+	}
+	var output, err = openOutput(fc.Output, fc.Rotation)
+	if err != nil {
+		return Config{}, err
+	}
+	c.Output = output
+	return c, nil
+}
+
+func (fc FileConfig) validate() error {
+	switch strings.ToLower(fc.Output) {
+	case "", OutputStdout, OutputStderr:
+		if fc.Rotation != nil {
+			return fmt.Errorf("rotation: output %q is not a file", fc.Output)
+		}
+	}
+	return nil
+}
+
+// Validate reports the first setting that is not valid, such as an
+// unrecognized level name. Errors name the setting as it appears in a
+// FileConfig, such as levels.billing. New calls Validate and logs any
+// error as an InvalidConfig event.
+func (c Config) Validate() error {
+	if err := validateLevel("level", c.Level); err != nil {
+		return err
+	}
+	var names = make([]string, 0, len(c.Levels))
+	for name := range c.Levels {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		if err := validateLevel("levels."+name, c.Levels[name]); err != nil {
+			return err
+		}
+	}
+	if err := validateEncoder("encoder", c.Encoder); err != nil {
+		return err
+	}
+	switch strings.ToLower(c.Async.Overflow) {
+	case "", OverflowBlock, OverflowDropNewest, OverflowDropOldest, OverflowDropBelowLevel:
+	default:
+		return fmt.Errorf("async.overflow: unknown policy %q", c.Async.Overflow)
+	}
+	if err := validateLevel("async.min_level", c.Async.MinLevel); err != nil {
+		return err
+	}
+	for name := range c.Sampling.Levels {
+		if _, ok := parseLevel(name); !ok {
+			return fmt.Errorf("sampling.levels: %w %q", ErrInvalidLevel, name)
+		}
+	}
+	return nil
+}
+
+// InvalidConfig is the event logged at ERROR by New when the Config fails
+// Validate. The Logger is still created, with the defaults in place of any
+// settings that are not recognized.
+type InvalidConfig struct {
+	Reason  string `logevent:"reason"`
+	Message string `logevent:"message,default=invalid-config"`
+}
+
+// validateEncoder checks the name with the same function that New uses to
+// select the encoder.
+func validateEncoder(key string, encoder string) error {
+	if _, err := newEncoderBackend(encoder, io.Discard); err != nil {
+		return fmt.Errorf("%s: %w", key, err)
+	}
+	return nil
+}
+
+// validateLevel accepts empty names, which select the default level.
+func validateLevel(key string, level string) error {
+	if level == "" {
+		return nil
+	}
+	if _, ok := parseLevel(level); !ok {
+		return fmt.Errorf("%s: %w %q", key, ErrInvalidLevel, level)
+	}
+	return nil
+}
+
+func openOutput(output string, rotation *RotationConfig) (io.Writer, error) {
+	switch strings.ToLower(output) {
+	case "", OutputStdout:
+		return os.Stdout, nil
+	case OutputStderr:
+		return os.Stderr, nil
+	}
+	if rotation != nil {
+		return NewRotatingFile(output, *rotation)
+	}
+	return os.OpenFile(output, os.O_WRONLY|os.O_APPEND|os.O_CREATE, 0644)
+}
+
+func decodeStrict(data []byte, v interface{}) error {
+	var decoder = yaml.NewDecoder(bytes.NewReader(data))
+	decoder.KnownFields(true)
+	var err = decoder.Decode(v)
+	if err == io.EOF {
+		return nil
+	}
+	return err
+}
+
+// parseFields parses a comma separated list of name=value pairs.
+func parseFields(spec string) (map[string]interface{}, error) {
+	var fields = make(map[string]interface{})
+	for _, pair := range splitList(spec) {
+		var name, value, ok = strings.Cut(pair, "=")
+		name = strings.TrimSpace(name)
+		if !ok || name == "" {
+			return nil, fmt.Errorf("invalid field %q", pair)
+		}
+		fields[name] = strings.TrimSpace(value)
+	}
+	return fields, nil
+}
+
+func splitList(spec string) []string {
+	var items []string
+	for _, item := range strings.Split(spec, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
@@ -0,0 +1,178 @@
+package logevent
+
+import (
+	"bytes"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseConfigYAML(t *testing.T) {
+	var c, err = ParseConfig([]byte(`
+level: info
+levels:
+  billing: debug
+encoder: logfmt
+output: stderr
+fields:
+  service: billing
+disable_caller: true
+redact_fields: [password]
+async:
+  enabled: true
+  overflow: drop_newest
+sampling:
+  levels:
+    DEBUG: {interval: 2s, first: 10, thereafter: 100}
+`))
+	require.NoError(t, err)
+	require.Equal(t, "info", c.Level)
+	require.Equal(t, map[string]string{"billing": "debug"}, c.Levels)
+	require.Equal(t, EncoderLogfmt, c.Encoder)
+	require.Equal(t, os.Stderr, c.Output)
+	require.Equal(t, map[string]interface{}{"service": "billing"}, c.Fields)
+	require.True(t, c.DisableCaller)
+	require.Equal(t, []string{"password"}, c.RedactFields)
+	require.Equal(t, AsyncConfig{Enabled: true, Overflow: OverflowDropNewest}, c.Async)
+	require.Equal(t, SamplingPolicy{Interval: 2 * time.Second, First: 10, Thereafter: 100}, c.Sampling.Levels["DEBUG"])
+}
+
+func TestParseConfigJSON(t *testing.T) {
+	var c, err = ParseConfig([]byte(`{"level": "WARN", "sampling": {"events": {"noisy": {"rate": 5}}}}`))
+	require.NoError(t, err)
+	require.Equal(t, "WARN", c.Level)
+	require.Equal(t, os.Stdout, c.Output)
+	require.Equal(t, 5.0, c.Sampling.Events["noisy"].Rate)
+
+	c, err = ParseConfig(nil)
+	require.NoError(t, err)
+	require.Equal(t, os.Stdout, c.Output)
+}
+
+func TestParseConfigInvalid(t *testing.T) {
+	for _, document := range []string{
+		`level: WARNING`,
+		`levels: {billing: loud}`,
+		`encoder: xml`,
+		`lvl: INFO`,
+		`async: {overflow: spill}`,
+		`async: {min_level: everything}`,
+		`sampling: {levels: {VERBOSE: {first: 1}}}`,
+		`sampling: {levels: {INFO: {interval: soon}}}`,
+	} {
+		var _, err = ParseConfig([]byte(document))
+		require.Error(t, err, document)
+	}
+	var _, err = ParseConfig([]byte(`level: WARNING`))
+	require.ErrorIs(t, err, ErrInvalidLevel)
+	_, err = ParseConfig([]byte(`encoder: xml`))
+	require.ErrorIs(t, err, ErrInvalidEncoder)
+}
+
+func TestParseConfigInvalidDoesNotOpenOutput(t *testing.T) {
+	var output = filepath.Join(t.TempDir(), "app.log")
+	var _, err = ParseConfig([]byte("output: " + output + "\nlevel: WARNING\n"))
+	require.ErrorIs(t, err, ErrInvalidLevel)
+	_, err = os.Stat(output)
+	require.True(t, os.IsNotExist(err), err)
+}
+
+func TestConfigValidate(t *testing.T) {
+	require.NoError(t, Config{Level: "info", Levels: map[string]string{"billing": "DEBUG"}}.Validate())
+	var err = Config{Level: "WARNING"}.Validate()
+	require.ErrorIs(t, err, ErrInvalidLevel)
+	require.Contains(t, err.Error(), "level")
+	err = Config{Levels: map[string]string{"billing": "loud"}}.Validate()
+	require.ErrorIs(t, err, ErrInvalidLevel)
+	require.Contains(t, err.Error(), "levels.billing")
+
+	var buff = &bytes.Buffer{}
+	New(Config{Output: buff, Levels: map[string]string{"billing": "loud"}})
+	var line = lastLine(t, buff)
+	require.Equal(t, "invalid-config", line["message"])
+	require.Equal(t, "error", line["level"])
+	require.Equal(t, err.Error(), line["reason"])
+}
+
+func TestLoadConfig(t *testing.T) {
+	var dir = t.TempDir()
+	var path = filepath.Join(dir, "log.yaml")
+	var output = filepath.Join(dir, "app.log")
+	require.NoError(t, os.WriteFile(path, []byte("output: "+output+"\ndisable_timestamp: true\nfields: {app: test}\n"), 0600))
+	var c, err = LoadConfig(path)
+	require.NoError(t, err)
+	New(c).Info("to the file")
+	require.NoError(t, c.Output.(*os.File).Close())
+
+	var data, _ = os.ReadFile(output)
+	var line map[string]interface{}
+	require.NoError(t, json.Unmarshal(data, &line))
+	require.Equal(t, "to the file", line["message"])
+	require.Equal(t, "test", line["app"])
+	require.NotContains(t, line, "time")
+
+	_, err = LoadConfig(filepath.Join(dir, "missing.yaml"))
+	require.Error(t, err)
+}
+
+func TestConfigFromEnv(t *testing.T) {
+	t.Setenv("APP_LOG_LEVEL", "error")
+	t.Setenv("APP_LOG_LEVELS", "billing=debug")
+	t.Setenv("APP_LOG_ENCODER", "ecs")
+	t.Setenv("APP_LOG_OUTPUT", "stdout")
+	t.Setenv("APP_LOG_FIELDS", "service=billing, region = us")
+	t.Setenv("APP_LOG_DISABLE_CALLER", "true")
+	t.Setenv("APP_LOG_REDACT_FIELDS", "password, token")
+	t.Setenv("APP_LOG_MAX_ELEMENTS", "-1")
+	t.Setenv("APP_LOG_MAX_DEPTH", "4")
+	t.Setenv("APP_LOG_ASYNC", `{"enabled": true, "size": 16}`)
+	t.Setenv("APP_LOG_SAMPLING", `{"levels": {"INFO": {"rate": 10}}}`)
+	var c, err = ConfigFromEnv("APP_LOG_")
+	require.NoError(t, err)
+	require.Equal(t, "error", c.Level)
+	require.Equal(t, map[string]string{"billing": "debug"}, c.Levels)
+	require.Equal(t, EncoderECS, c.Encoder)
+	require.Equal(t, map[string]interface{}{"service": "billing", "region": "us"}, c.Fields)
+	require.True(t, c.DisableCaller)
+	require.False(t, c.DisableTimestamp)
+	require.Equal(t, []string{"password", "token"}, c.RedactFields)
+	require.Equal(t, -1, c.MaxElements)
+	require.Equal(t, 4, c.MaxDepth)
+	require.Equal(t, AsyncConfig{Enabled: true, Size: 16}, c.Async)
+	require.Equal(t, 10.0, c.Sampling.Levels["INFO"].Rate)
+}
+
+func TestConfigFromEnvInvalid(t *testing.T) {
+	for name, value := range map[string]string{
+		"LEVEL":          "WARNING",
+		"ENCODER":        "xml",
+		"LEVELS":         "billing",
+		"FIELDS":         "=value",
+		"HUMAN_READABLE": "sometimes",
+		"MAX_ELEMENTS":   "many",
+		"MAX_DEPTH":      "deep",
+		"ASYNC":          "{size: big}",
+		"SAMPLING":       "{unknown: 1}",
+	} {
+		t.Run(name, func(t *testing.T) {
+			t.Setenv("APP_LOG_"+name, value)
+			var _, err = ConfigFromEnv("APP_LOG_")
+			require.Error(t, err)
+			require.True(t, strings.Contains(err.Error(), name), err.Error())
+		})
+	}
+}
+
+func TestDisableCallerAndTimestamp(t *testing.T) {
+	var buff = &bytes.Buffer{}
+	New(Config{Output: buff, DisableCaller: true, DisableTimestamp: true}).Info("bare")
+	var line map[string]interface{}
+	require.NoError(t, json.Unmarshal(buff.Bytes(), &line))
+	require.NotContains(t, line, "time")
+	require.NotContains(t, line, callerKey)
+}
@@ -1,6 +1,10 @@
 package logevent
 
-import "context"
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+)
 
 type ctxKey string
 
@@ -8,12 +12,61 @@ const (
 	logeventKey = ctxKey("__logevent_ctx_key")
 )
 
+// defaultLogger holds the loggerHolder returned when a context has no Logger.
+var defaultLogger atomic.Value
+
+// fallbackLogger is created on first use if no default is installed.
+var (
+	fallbackLogger Logger
+	fallbackOnce   sync.Once
+)
+
+// loggerHolder gives every value stored in defaultLogger the same concrete
+// type as required by atomic.Value.
+type loggerHolder struct {
+	logger Logger
+}
+
+// SetDefault installs the Logger returned by FromContext when a context
+// does not contain one. It is intended to be called once during start up.
+// Passing nil restores the original default, which writes to os.Stdout.
+func SetDefault(logger Logger) {
+	defaultLogger.Store(loggerHolder{logger: logger})
+}
+
+// Default returns the Logger installed with SetDefault. If none has been
+// installed then a Logger created with an empty Config is returned.
+func Default() Logger {
+	if holder, ok := defaultLogger.Load().(loggerHolder); ok && holder.logger != nil {
+		return holder.logger
+	}
+	fallbackOnce.Do(func() {
+		fallbackLogger = New(Config{})
+	})
+	return fallbackLogger
+}
+
 // NewContext installs a Logger.
 func NewContext(ctx context.Context, logger Logger) context.Context {
 	return context.WithValue(ctx, logeventKey, logger)
 }
 
-// FromContext fetches a Logger.
+// FromContext fetches a Logger. A copy of the Default Logger is returned if
+// the context does not contain one so that it is always safe to log, and
+// so that SetField on the result does not change the Default Logger.
 func FromContext(ctx context.Context) Logger {
-	return ctx.Value(logeventKey).(Logger)
+	if logger, ok := FromContextOk(ctx); ok {
+		return logger
+	}
+	return Default().Copy()
+}
+
+// FromContextOk fetches a Logger. The second return is false if the
+// context does not contain one.
+func FromContextOk(ctx context.Context) (Logger, bool) {
+	if ctx == nil {
+		return nil, false
+	}
+	var logger, ok = ctx.Value(logeventKey).(Logger)
+	return logger, ok && logger != nil
 }
@@ -0,0 +1,61 @@
+package logevent
+
+import (
+	"bytes"
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestFromContext(t *testing.T) {
+	var logger = NewNop()
+	var ctx = NewContext(context.Background(), logger)
+	require.Equal(t, logger, FromContext(ctx))
+	var found, ok = FromContextOk(ctx)
+	require.True(t, ok)
+	require.Equal(t, logger, found)
+}
+
+func TestFromContextMissing(t *testing.T) {
+	defer SetDefault(nil)
+
+	var _, ok = FromContextOk(context.Background())
+	require.False(t, ok)
+	//nolint:staticcheck // a nil context must not panic
+	_, ok = FromContextOk(nil)
+	require.False(t, ok)
+	_, ok = FromContextOk(NewContext(context.Background(), nil))
+	require.False(t, ok)
+
+	require.NotNil(t, FromContext(context.Background()))
+
+	var buff = &bytes.Buffer{}
+	var installed = New(Config{Output: buff})
+	SetDefault(installed)
+	require.Equal(t, installed, Default())
+	FromContext(context.Background()).Info("to the default")
+	require.Contains(t, buff.String(), "to the default")
+
+	// fields set on the result do not leak in to the default
+	FromContext(context.Background()).SetField("leaked", true)
+	Default().Info("default")
+	require.NotContains(t, lastLine(t, buff), "leaked")
+
+	SetDefault(nil)
+	require.NotEqual(t, installed, Default())
+	require.NotNil(t, Default())
+}
+
+func TestNop(t *testing.T) {
+	var logger = NewNop()
+	logger.SetField("key", "value")
+	logger.Debug("discarded")
+	logger.Info("discarded")
+	logger.Warn("discarded")
+	logger.Error("discarded")
+	logger.Trace("discarded")
+	logger.Fatal("discarded")
+	require.Panics(t, func() { logger.Panic("discarded") })
+	require.Equal(t, logger, logger.Copy())
+}
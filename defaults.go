@@ -0,0 +1,157 @@
+package logevent
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ErrInvalidTag is returned by ValidateEvent for a logevent tag that has an
+// unknown option or a default that cannot be used for its field.
+var ErrInvalidTag = errors.New("invalid logevent tag")
+
+// escapedComma is a comma within a tag option, such as a default, that
+// does not end the option. It separates the elements of a slice default.
+const escapedComma = `\,`
+
+// splitTag splits a tag on the commas that are not escaped.
+func splitTag(tag string) []string {
+	var parts []string
+	var start = 0
+	for x := 0; x < len(tag); x = x + 1 {
+		if tag[x] == ',' && (x == 0 || tag[x-1] != '\\') {
+			parts = append(parts, tag[start:x])
+			start = x + 1
+		}
+	}
+	return append(parts, tag[start:])
+}
+
+func unescapeTag(value string) string {
+	return strings.ReplaceAll(value, escapedComma, ",")
+}
+
+// getDefaultValue parses the default from a tag in to a value of the type
+// of the field. The zero value is returned along with any error.
+func getDefaultValue(t reflect.Type, value string) (interface{}, error) {
+	var v, err = parseDefault(t, value)
+	if err != nil {
+		return reflect.Zero(t).Interface(), err
+	}
+	return v.Interface(), nil
+}
+
+// parseDefault supports strings, bools, numbers, time.Duration in Go
+// syntax, times in RFC 3339, and pointers to and slices of any of those.
+// Named types are supported by their underlying kind. The elements of a
+// slice are separated by escaped commas.
+func parseDefault(t reflect.Type, value string) (reflect.Value, error) {
+	var result = reflect.New(t).Elem()
+	switch {
+	case t.Kind() == reflect.Ptr:
+		var elem, err = parseDefault(t.Elem(), value)
+		if err != nil {
+			return result, err
+		}
+		result.Set(reflect.New(t.Elem()))
+		result.Elem().Set(elem)
+		return result, nil
+	case t.Kind() == reflect.Slice:
+		if value == "" {
+			return reflect.MakeSlice(t, 0, 0), nil
+		}
+		var parts = strings.Split(value, escapedComma)
+		result.Set(reflect.MakeSlice(t, len(parts), len(parts)))
+		for x, part := range parts {
+			var elem, err = parseDefault(t.Elem(), part)
+			if err != nil {
+				return result, err
+			}
+			result.Index(x).Set(elem)
+		}
+		return result, nil
+	}
+	value = unescapeTag(value)
+	switch {
+	case t == durationType:
+		var d, err = time.ParseDuration(value)
+		result.Set(reflect.ValueOf(d))
+		return result, err
+	case t.ConvertibleTo(timeType) && t.Kind() == reflect.Struct:
+		var parsed, err = time.Parse(time.RFC3339Nano, value)
+		result.Set(reflect.ValueOf(parsed).Convert(t))
+		return result, err
+	}
+	switch t.Kind() {
+	case reflect.String:
+		result.SetString(value)
+		return result, nil
+	case reflect.Bool:
+		var b, err = strconv.ParseBool(value)
+		result.SetBool(b)
+		return result, err
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		var n, err = strconv.ParseInt(value, 10, t.Bits())
+		result.SetInt(n)
+		return result, err
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		var n, err = strconv.ParseUint(value, 10, t.Bits())
+		result.SetUint(n)
+		return result, err
+	case reflect.Float32, reflect.Float64:
+		var f, err = strconv.ParseFloat(value, t.Bits())
+		result.SetFloat(f)
+		return result, err
+	default:
+		return result, fmt.Errorf("defaults are not supported for %s", t)
+	}
+}
+
+// ValidateEvent reports the problems with the logevent tags of an event
+// type and of the struct types that it contains, such as a default that
+// cannot be parsed for its field. It is intended to be called at start up,
+// or in a test, for each event type so that mistakes are found before
+// events are logged. Nil is returned if every tag is valid.
+//
+//	if err := logevent.ValidateEvent(UserOverLimit{}); err != nil {
+//	  panic(err)
+//	}
+func ValidateEvent(event interface{}) error {
+	var t = reflect.TypeOf(event)
+	if t == nil {
+		return nil
+	}
+	var errs []error
+	validateType(t, make(map[reflect.Type]bool), &errs)
+	return errors.Join(errs...)
+}
+
+func validateType(t reflect.Type, seen map[reflect.Type]bool, errs *[]error) {
+	for t.Kind() == reflect.Ptr || t.Kind() == reflect.Slice || t.Kind() == reflect.Array || t.Kind() == reflect.Map {
+		t = t.Elem()
+	}
+	if t.Kind() != reflect.Struct || seen[t] {
+		return
+	}
+	seen[t] = true
+	for x := 0; x < t.NumField(); x = x + 1 {
+		var field = t.Field(x)
+		var tag = field.Tag.Get(tagKey)
+		if field.PkgPath != "" || tag == "-" {
+			continue
+		}
+		var options = parseTag(tag)
+		for _, option := range options.unknown {
+			*errs = append(*errs, fmt.Errorf("%w on %s.%s: unknown option %q", ErrInvalidTag, t, field.Name, option))
+		}
+		if options.hasDefault {
+			if _, err := getDefaultValue(field.Type, options.def); err != nil {
+				*errs = append(*errs, fmt.Errorf("%w on %s.%s: default %q: %v", ErrInvalidTag, t, field.Name, options.def, err))
+			}
+		}
+		validateType(field.Type, seen, errs)
+	}
+}
@@ -0,0 +1,79 @@
+package logevent
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+type level string
+
+type eventRichDefaults struct {
+	Timeout  time.Duration `logevent:"timeout,default=1m30s"`
+	Since    time.Time     `logevent:"since,default=2024-01-02T03:04:05Z"`
+	Retries  uint16        `logevent:"retries,default=3"`
+	Tags     []string      `logevent:"tags,default=a\\,b\\,c"`
+	Ports    []int         `logevent:"ports,default=80\\,443"`
+	Limit    *int          `logevent:"limit,default=10"`
+	Level    level         `logevent:"level,default=info"`
+	Greeting string        `logevent:"greeting,default=hello\\, world"`
+	Message  string        `logevent:"message,default=rich\\, defaults"`
+}
+
+type eventInvalidDefaults struct {
+	Count   int               `logevent:"count,default=many"`
+	Timeout time.Duration     `logevent:"timeout,default=soon,omitempy"`
+	Labels  map[string]string `logevent:"labels,default=a"`
+	Nested  []eventBadNested  `logevent:"nested"`
+	Card    string            `logevent:"card,mask=lst4"`
+	Trailed string            `logevent:"trailed,omitempty,"`
+	Message string            `logevent:"message,default=invalid"`
+}
+
+type eventBadNested struct {
+	When *time.Time `logevent:"when,default=yesterday"`
+}
+
+func TestRichDefaults(t *testing.T) {
+	var message, annotations = Render(eventRichDefaults{})
+	require.Equal(t, "rich, defaults", message)
+	require.Equal(t, 90*time.Second, annotations["timeout"])
+	require.Equal(t, time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC), annotations["since"])
+	require.Equal(t, uint16(3), annotations["retries"])
+	require.Equal(t, []string{"a", "b", "c"}, annotations["tags"])
+	require.Equal(t, []int{80, 443}, annotations["ports"])
+	require.Equal(t, 10, *annotations["limit"].(*int))
+	require.Equal(t, level("info"), annotations["level"])
+	require.Equal(t, "hello, world", annotations["greeting"])
+	require.NoError(t, ValidateEvent(eventRichDefaults{}))
+	require.NoError(t, ValidateEvent(nil))
+}
+
+func TestValidateEvent(t *testing.T) {
+	var err = ValidateEvent(&eventInvalidDefaults{})
+	require.True(t, errors.Is(err, ErrInvalidTag))
+	for _, expected := range []string{
+		`logevent.eventInvalidDefaults.Count: default "many"`,
+		`logevent.eventInvalidDefaults.Timeout: unknown option "omitempy"`,
+		`logevent.eventInvalidDefaults.Timeout: default "soon"`,
+		`logevent.eventInvalidDefaults.Labels: default "a": defaults are not supported for map[string]string`,
+		`logevent.eventBadNested.When: default "yesterday"`,
+		`logevent.eventInvalidDefaults.Card: unknown option "mask=lst4"`,
+	} {
+		require.Contains(t, err.Error(), expected)
+	}
+	require.NotContains(t, err.Error(), "Trailed")
+
+	var _, annotations = Render(eventInvalidDefaults{Card: "4111"})
+	require.Equal(t, 0, annotations["count"])
+	require.Equal(t, time.Duration(0), annotations["timeout"])
+	require.Equal(t, "****", annotations["card"])
+	require.NotContains(t, annotations, "trailed")
+}
+
+func TestSplitTag(t *testing.T) {
+	require.Equal(t, []string{"name", `default=a\,b`, "omitempty"}, splitTag(`name,default=a\,b,omitempty`))
+	require.Equal(t, []string{""}, splitTag(""))
+}
@@ -0,0 +1,364 @@
+package logevent
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/rs/zerolog"
+)
+
+// Encoders that may be selected with Config.Encoder.
+const (
+	// EncoderJSON renders each event as a JSON object. This is the default.
+	EncoderJSON = "json"
+	// EncoderLogfmt renders each event as a line of space separated
+	// key=value pairs. Nested annotations use dotted keys.
+	EncoderLogfmt = "logfmt"
+	// EncoderECS renders each event as a JSON object that follows the
+	// Elastic Common Schema logging conventions.
+	EncoderECS = "ecs"
+	// EncoderGELF renders each event as a newline delimited GELF 1.1
+	// message for Graylog.
+	EncoderGELF = "gelf"
+	// EncoderRFC5424 renders each event as an RFC 5424 syslog message with
+	// the annotations as structured data. Use with a SyslogWriter.
+	EncoderRFC5424 = "rfc5424"
+	// EncoderJournald renders each event in the systemd-journald native
+	// protocol. Use with a JournalWriter.
+	EncoderJournald = "journald"
+)
+
+const (
+	ecsVersion  = "8.11.0"
+	gelfVersion = "1.1"
+	gelfFile    = "_file"
+	gelfLine    = "_line"
+)
+
+// encodeFunc appends a single rendered record to the buffer. The buffer is
+// written to the output in a single call.
+type encodeFunc func(buf *bytes.Buffer, r *record)
+
+type encoderBackend struct {
+	w      io.Writer
+	encode encodeFunc
+}
+
+func (b *encoderBackend) write(r *record) {
+	var buf = &bytes.Buffer{}
+	b.encode(buf, r)
+	_, _ = b.w.Write(buf.Bytes())
+}
+
+// ErrInvalidEncoder is returned when an encoder name is not recognized.
+var ErrInvalidEncoder = errors.New("unknown encoder")
+
+// newEncoderBackend returns the backend for the named encoder. The backend
+// is nil for JSON, which is implemented by zerolog, and ErrInvalidEncoder
+// is returned if the name is not recognized.
+func newEncoderBackend(name string, w io.Writer) (backend, error) {
+	switch strings.ToLower(name) {
+	case "", EncoderJSON:
+		return nil, nil
+	case EncoderLogfmt:
+		return &encoderBackend{w: w, encode: encodeLogfmt}, nil
+	case EncoderECS:
+		return &encoderBackend{w: w, encode: encodeECS}, nil
+	case EncoderGELF:
+		var host, _ = os.Hostname()
+		return &encoderBackend{w: w, encode: gelfEncoder(host)}, nil
+	case EncoderRFC5424:
+		return &encoderBackend{w: w, encode: newRFC5424Encoder()}, nil
+	case EncoderJournald:
+		return &encoderBackend{w: w, encode: newJournalEncoder()}, nil
+	default:
+		return nil, fmt.Errorf("%w %q", ErrInvalidEncoder, name)
+	}
+}
+
+// flatten copies nested annotations in to a single level map using the
+// separator to join keys.
+func flatten(dst map[string]interface{}, prefix string, sep string, src map[string]interface{}) {
+	for key, value := range src {
+		if prefix != "" {
+			key = prefix + sep + key
+		}
+		if nested, ok := value.(map[string]interface{}); ok {
+			flatten(dst, key, sep, nested)
+			continue
+		}
+		dst[key] = value
+	}
+}
+
+func sortedKeys(m map[string]interface{}) []string {
+	var keys = make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// jsonValue converts values that encoding/json renders poorly in to the
+// same shape that the default backend uses.
+func jsonValue(value interface{}) interface{} {
+	switch v := value.(type) {
+	case error:
+		return v.Error()
+	case time.Duration:
+		return float64(v) / float64(time.Millisecond)
+	case map[string]interface{}:
+		var out = make(map[string]interface{}, len(v))
+		for key, nested := range v {
+			out[key] = jsonValue(nested)
+		}
+		return out
+	default:
+		return value
+	}
+}
+
+func writeJSON(buf *bytes.Buffer, value interface{}) {
+	var b, err = json.Marshal(jsonValue(value))
+	if err != nil {
+		b, _ = json.Marshal(fmt.Sprint(value))
+	}
+	buf.Write(b)
+}
+
+func writeJSONField(buf *bytes.Buffer, key string, value interface{}) {
+	if buf.Len() > 1 {
+		buf.WriteByte(',')
+	}
+	writeJSON(buf, key)
+	buf.WriteByte(':')
+	writeJSON(buf, value)
+}
+
+func encodeLogfmt(buf *bytes.Buffer, r *record) {
+	writeLogfmtField(buf, zerolog.LevelFieldName, r.level.String())
+	if !r.time.IsZero() {
+		writeLogfmtField(buf, zerolog.TimestampFieldName, r.time)
+	}
+	if caller := r.caller(); caller != "" {
+		writeLogfmtField(buf, callerKey, caller)
+	}
+	writeLogfmtField(buf, zerolog.MessageFieldName, r.message)
+	var fields = make(map[string]interface{}, len(r.fields))
+	flatten(fields, "", ".", r.fields)
+	for _, key := range sortedKeys(fields) {
+		writeLogfmtField(buf, key, fields[key])
+	}
+	buf.WriteByte('\n')
+}
+
+func writeLogfmtField(buf *bytes.Buffer, key string, value interface{}) {
+	if buf.Len() > 0 {
+		buf.WriteByte(' ')
+	}
+	buf.WriteString(logfmtKey(key))
+	buf.WriteByte('=')
+	buf.WriteString(logfmtValue(value))
+}
+
+// logfmtKey replaces characters that would break key=value parsing.
+func logfmtKey(key string) string {
+	if key == "" {
+		return "_"
+	}
+	return strings.Map(func(r rune) rune {
+		if r <= ' ' || r == '=' || r == '"' || r == utf8.RuneError {
+			return '_'
+		}
+		return r
+	}, key)
+}
+
+func logfmtValue(value interface{}) string {
+	switch value.(type) {
+	case nil:
+		return ""
+	case bool, int, int8, int16, int32, int64, uint, uint8, uint16, uint32, uint64, float32, float64, time.Time:
+		return textValue(value)
+	}
+	var s = textValue(value)
+	if s != "" && strings.IndexFunc(s, needsQuote) < 0 {
+		return s
+	}
+	return strconv.Quote(s)
+}
+
+// textValue renders an annotation value as unquoted text. Values without a
+// natural text form are rendered as JSON.
+func textValue(value interface{}) string {
+	switch v := value.(type) {
+	case nil:
+		return ""
+	case string:
+		return v
+	case bool:
+		return strconv.FormatBool(v)
+	case int, int8, int16, int32, int64, uint, uint8, uint16, uint32, uint64, float32, float64:
+		return fmt.Sprint(v)
+	case time.Time:
+		return v.Format(time.RFC3339Nano)
+	case error:
+		return v.Error()
+	case fmt.Stringer:
+		return v.String()
+	default:
+		var b, err = json.Marshal(jsonValue(value))
+		if err != nil {
+			return fmt.Sprint(value)
+		}
+		return string(b)
+	}
+}
+
+func needsQuote(r rune) bool {
+	return r <= ' ' || r == '=' || r == '"' || r == '\\' || !unicode.IsPrint(r)
+}
+
+// ecsReserved are the keys written by the ECS encoder itself. Annotations
+// with the same names are dropped rather than producing duplicate keys.
+var ecsReserved = map[string]bool{
+	"@timestamp":           true,
+	"log.level":            true,
+	"message":              true,
+	"ecs.version":          true,
+	"log.origin.file.name": true,
+	"log.origin.file.line": true,
+	"log.origin.function":  true,
+}
+
+func encodeECS(buf *bytes.Buffer, r *record) {
+	buf.WriteByte('{')
+	if !r.time.IsZero() {
+		writeJSONField(buf, "@timestamp", r.time.UTC().Format(time.RFC3339Nano))
+	}
+	writeJSONField(buf, "log.level", r.level.String())
+	writeJSONField(buf, "message", r.message)
+	writeJSONField(buf, "ecs.version", ecsVersion)
+	if frame := r.frame(); frame.File != "" {
+		writeJSONField(buf, "log.origin.file.name", filepath.Base(frame.File))
+		writeJSONField(buf, "log.origin.file.line", frame.Line)
+		if frame.Function != "" {
+			writeJSONField(buf, "log.origin.function", frame.Function)
+		}
+	}
+	for _, key := range sortedKeys(r.fields) {
+		if ecsReserved[key] {
+			continue
+		}
+		writeJSONField(buf, key, r.fields[key])
+	}
+	buf.WriteString("}\n")
+}
+
+// syslogSeverity maps levels on to syslog severities. They are used by
+// GELF, RFC 5424, and journald. Emergency is never used because syslog
+// daemons and journald broadcast it to every terminal on the host.
+func syslogSeverity(level zerolog.Level) int {
+	switch level {
+	case zerolog.TraceLevel, zerolog.DebugLevel:
+		return 7
+	case zerolog.InfoLevel:
+		return 6
+	case zerolog.WarnLevel:
+		return 4
+	case zerolog.ErrorLevel:
+		return 3
+	case zerolog.FatalLevel:
+		return 2
+	case zerolog.PanicLevel:
+		return 1
+	default:
+		return 6
+	}
+}
+
+// gelfKey converts an annotation name in to a GELF additional field name.
+// GELF only permits word characters, dots, and dashes and reserves _id.
+func gelfKey(key string) string {
+	key = strings.Map(func(r rune) rune {
+		if r == '.' || r == '-' || r == '_' || unicode.IsLetter(r) || unicode.IsDigit(r) {
+			return r
+		}
+		return '_'
+	}, key)
+	if key == "id" {
+		key = "_id"
+	}
+	return "_" + key
+}
+
+// gelfValue converts a value in to a string or number as required for
+// GELF additional fields.
+func gelfValue(value interface{}) interface{} {
+	switch v := value.(type) {
+	case string:
+		return v
+	case int, int8, int16, int32, int64, uint, uint8, uint16, uint32, uint64, float32, float64:
+		return v
+	default:
+		return logfmtString(value)
+	}
+}
+
+// logfmtString renders a value as an unquoted string.
+func logfmtString(value interface{}) string {
+	if s, ok := value.(string); ok {
+		return s
+	}
+	var s = logfmtValue(value)
+	if unquoted, err := strconv.Unquote(s); err == nil {
+		return unquoted
+	}
+	return s
+}
+
+func gelfEncoder(host string) encodeFunc {
+	if host == "" {
+		host = unknown
+	}
+	return func(buf *bytes.Buffer, r *record) {
+		buf.WriteByte('{')
+		writeJSONField(buf, "version", gelfVersion)
+		writeJSONField(buf, "host", host)
+		writeJSONField(buf, "short_message", r.message)
+		if !r.time.IsZero() {
+			writeJSONField(buf, "timestamp", float64(r.time.UnixMilli())/1000)
+		}
+		writeJSONField(buf, "level", syslogSeverity(r.level))
+		var written = map[string]bool{}
+		if frame := r.frame(); frame.File != "" {
+			writeJSONField(buf, gelfFile, frame.File)
+			writeJSONField(buf, gelfLine, frame.Line)
+			written[gelfFile] = true
+			written[gelfLine] = true
+		}
+		var fields = make(map[string]interface{}, len(r.fields))
+		flatten(fields, "", "_", r.fields)
+		for _, key := range sortedKeys(fields) {
+			var name = gelfKey(key)
+			if written[name] {
+				continue
+			}
+			written[name] = true
+			writeJSONField(buf, name, gelfValue(fields[key]))
+		}
+		buf.WriteString("}\n")
+	}
+}
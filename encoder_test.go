@@ -0,0 +1,131 @@
+package logevent
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/require"
+)
+
+type encoderEvent struct {
+	Message string                 `logevent:"message,default=encoded"`
+	Text    string                 `logevent:"text"`
+	Count   int                    `logevent:"count"`
+	Err     error                  `logevent:"err"`
+	Nested  EventWithNestedStructs `logevent:"nested"`
+}
+
+func TestEncoderLogfmt(t *testing.T) {
+	var buff = &bytes.Buffer{}
+	var logger = New(Config{Output: buff, Encoder: EncoderLogfmt})
+	logger.SetField("out-of-event", true)
+	logger.Info(encoderEvent{
+		Text:   "has spaces and \"quotes\"",
+		Count:  3,
+		Err:    errors.New("boom"),
+		Nested: EventWithNestedStructs{Nested: EmbeddedStruct{Two: timeField}},
+	})
+
+	var line = strings.TrimSuffix(buff.String(), "\n")
+	require.True(t, strings.HasPrefix(line, "level=info time="), line)
+	require.Contains(t, line, " file=")
+	require.Contains(t, line, "encoder_test.go:")
+	require.Contains(t, line, " message=encoded")
+	require.Contains(t, line, ` text="has spaces and \"quotes\""`)
+	require.Contains(t, line, " count=3")
+	require.Contains(t, line, " err.message=boom")
+	require.Contains(t, line, " err.kind=*errors.errorString")
+	require.Contains(t, line, " out-of-event=true")
+	require.Contains(t, line, " nested.message=testvalue")
+	require.Contains(t, line, " nested.nested.one=foo")
+	require.Contains(t, line, " nested.nested.two="+timeField.Format(time.RFC3339Nano))
+	require.Equal(t, 1, strings.Count(buff.String(), "\n"))
+}
+
+func TestLogfmtValue(t *testing.T) {
+	require.Equal(t, "", logfmtValue(nil))
+	require.Equal(t, `""`, logfmtValue(""))
+	require.Equal(t, "false", logfmtValue(false))
+	require.Equal(t, "1.5", logfmtValue(1.5))
+	require.Equal(t, `"a=b"`, logfmtValue("a=b"))
+	require.Equal(t, `"[\"a\",\"b\"]"`, logfmtValue([]string{"a", "b"}))
+	require.Equal(t, "1s", logfmtValue(time.Second))
+	require.Equal(t, "a_b_", logfmtKey("a b="))
+}
+
+func TestEncoderECS(t *testing.T) {
+	var buff = &bytes.Buffer{}
+	var logger = New(Config{Output: buff, Encoder: EncoderECS})
+	logger.SetField("message", "not me")
+	logger.SetField("labels", map[string]interface{}{"team": "security"})
+	logger.Error(encoderEvent{Err: errors.New("boom")})
+
+	var line = make(map[string]interface{})
+	require.Nil(t, json.Unmarshal(buff.Bytes(), &line))
+	require.True(t, strings.HasPrefix(buff.String(), `{"@timestamp":`), buff.String())
+	require.Equal(t, "error", line["log.level"])
+	require.Equal(t, "encoded", line["message"])
+	require.Equal(t, ecsVersion, line["ecs.version"])
+	require.Equal(t, "encoder_test.go", line["log.origin.file.name"])
+	require.NotZero(t, line["log.origin.file.line"])
+	require.Equal(t, "boom", line["err"].(map[string]interface{})["message"])
+	require.Equal(t, "security", line["labels"].(map[string]interface{})["team"])
+	var _, err = time.Parse(time.RFC3339Nano, line["@timestamp"].(string))
+	require.Nil(t, err)
+}
+
+func TestEncoderGELF(t *testing.T) {
+	var buff = &bytes.Buffer{}
+	var logger = New(Config{Output: buff, Encoder: EncoderGELF})
+	logger.SetField("id", "reserved")
+	logger.SetField("enabled", true)
+	logger.SetField("file", "duplicate")
+	logger.Warn(encoderEvent{Count: 2})
+
+	require.True(t, strings.HasSuffix(buff.String(), "}\n"))
+	var line = make(map[string]interface{})
+	require.Nil(t, json.Unmarshal(buff.Bytes(), &line))
+	require.Equal(t, gelfVersion, line["version"])
+	require.NotEmpty(t, line["host"])
+	require.Equal(t, "encoded", line["short_message"])
+	require.Equal(t, 4.0, line["level"])
+	require.NotZero(t, line["timestamp"])
+	require.Contains(t, line["_file"], "encoder_test.go")
+	require.NotZero(t, line["_line"])
+	require.Equal(t, 2.0, line["_count"])
+	require.Equal(t, "true", line["_enabled"])
+	require.Equal(t, "reserved", line["__id"])
+	require.Equal(t, "testvalue", line["_nested_message"])
+	require.Equal(t, "foo", line["_nested_nested_one"])
+}
+
+func TestSyslogSeverity(t *testing.T) {
+	require.Equal(t, 7, syslogSeverity(zerolog.DebugLevel))
+	require.Equal(t, 3, syslogSeverity(zerolog.ErrorLevel))
+	require.Equal(t, 2, syslogSeverity(zerolog.FatalLevel))
+	require.Equal(t, 1, syslogSeverity(zerolog.PanicLevel))
+}
+
+func TestEncoderUnknownFallsBackToJSON(t *testing.T) {
+	var buff = &bytes.Buffer{}
+	var logger = New(Config{Output: buff, Encoder: "unknown"})
+	var decoder = json.NewDecoder(buff)
+	var line = make(map[string]interface{})
+	require.Nil(t, decoder.Decode(&line))
+	require.Equal(t, "invalid-config", line["message"])
+	require.Equal(t, "error", line["level"])
+	require.Equal(t, `encoder: unknown encoder "unknown"`, line["reason"])
+	require.ErrorIs(t, Config{Encoder: "unknown"}.Validate(), ErrInvalidEncoder)
+	var _, err = newEncoderBackend("unknown", buff)
+	require.ErrorIs(t, err, ErrInvalidEncoder)
+
+	logger.Info("hello")
+	line = make(map[string]interface{})
+	require.Nil(t, decoder.Decode(&line))
+	require.Equal(t, "hello", line["message"])
+}
@@ -0,0 +1,146 @@
+package logevent
+
+import (
+	"fmt"
+	"reflect"
+	"runtime"
+)
+
+const (
+	errorMessageKey = "message"
+	errorKindKey    = "kind"
+	errorChainKey   = "chain"
+	errorStackKey   = "stack"
+	// eventErrorKey is the annotation of an event that is itself an error.
+	eventErrorKey = "error"
+)
+
+const (
+	// maxErrorChain limits the number of causes rendered for an error so
+	// that an Unwrap cycle cannot loop forever.
+	maxErrorChain = 32
+	// maxStackDepth limits the number of frames in a stack trace.
+	maxStackDepth = 32
+)
+
+// errorValue returns the error held by a field. The second return is false
+// for nil values and for values that are not errors.
+func errorValue(v reflect.Value) (error, bool) {
+	if v.Kind() == reflect.Interface {
+		if v.IsNil() {
+			return nil, false
+		}
+		v = v.Elem()
+	}
+	if v.Kind() == reflect.Ptr && v.IsNil() {
+		return nil, false
+	}
+	var err, ok = v.Interface().(error)
+	return err, ok
+}
+
+// renderError converts an error in to an object with its message, its Go
+// type as the kind, the chain of errors that it wraps, and a stack trace.
+// The stack is taken from the first error in the chain that carries one,
+// or from the given stack if none do.
+func renderError(err error, stack []uintptr) map[string]interface{} {
+	var rendered = map[string]interface{}{
+		errorMessageKey: err.Error(),
+		errorKindKey:    errorKind(err),
+	}
+	var causes = errorCauses(err)
+	if len(causes) > 0 {
+		var chain = make([]interface{}, 0, len(causes))
+		for _, cause := range causes {
+			chain = append(chain, map[string]interface{}{
+				errorMessageKey: cause.Error(),
+				errorKindKey:    errorKind(cause),
+			})
+		}
+		rendered[errorChainKey] = chain
+	}
+	for _, e := range append([]error{err}, causes...) {
+		if pcs := errorStack(e); len(pcs) > 0 {
+			stack = pcs
+			break
+		}
+	}
+	if len(stack) > 0 {
+		rendered[errorStackKey] = formatStack(stack)
+	}
+	return rendered
+}
+
+func errorKind(err error) string {
+	return fmt.Sprintf("%T", err)
+}
+
+// errorCauses lists the errors wrapped by err, depth first, following both
+// Unwrap() error and the Unwrap() []error of errors.Join.
+func errorCauses(err error) []error {
+	var causes []error
+	var pending = unwrapError(err)
+	for len(pending) > 0 && len(causes) < maxErrorChain {
+		var cause = pending[0]
+		pending = append(unwrapError(cause), pending[1:]...)
+		causes = append(causes, cause)
+	}
+	return causes
+}
+
+func unwrapError(err error) []error {
+	var causes []error
+	switch e := err.(type) {
+	case interface{ Unwrap() error }:
+		causes = []error{e.Unwrap()}
+	case interface{ Unwrap() []error }:
+		causes = e.Unwrap()
+	}
+	var found = make([]error, 0, len(causes))
+	for _, cause := range causes {
+		if cause != nil {
+			found = append(found, cause)
+		}
+	}
+	return found
+}
+
+// errorStack returns the program counters recorded by an error. Errors may
+// provide them with a Callers() []uintptr method or, as with
+// github.com/pkg/errors, a StackTrace method that returns a slice of an
+// uintptr based frame type.
+func errorStack(err error) []uintptr {
+	if e, ok := err.(interface{ Callers() []uintptr }); ok {
+		return e.Callers()
+	}
+	var method = reflect.ValueOf(err).MethodByName("StackTrace")
+	if !method.IsValid() {
+		return nil
+	}
+	var t = method.Type()
+	if t.NumIn() != 0 || t.NumOut() != 1 || t.Out(0).Kind() != reflect.Slice || t.Out(0).Elem().Kind() != reflect.Uintptr {
+		return nil
+	}
+	var frames = method.Call(nil)[0]
+	var pcs = make([]uintptr, frames.Len())
+	for x := range pcs {
+		pcs[x] = uintptr(frames.Index(x).Uint())
+	}
+	return pcs
+}
+
+// formatStack renders program counters as "function file:line" strings.
+func formatStack(pcs []uintptr) []string {
+	var stack = make([]string, 0, len(pcs))
+	var frames = runtime.CallersFrames(pcs)
+	for len(stack) < maxStackDepth {
+		var frame, more = frames.Next()
+		if frame.Function != "" || frame.File != "" {
+			stack = append(stack, fmt.Sprintf("%s %s:%d", frame.Function, frame.File, frame.Line))
+		}
+		if !more {
+			break
+		}
+	}
+	return stack
+}
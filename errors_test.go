@@ -0,0 +1,102 @@
+package logevent
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"runtime"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+type eventWithError struct {
+	Message string `logevent:"message,default=failed"`
+	Err     error  `logevent:"error"`
+}
+
+type stackError struct {
+	pcs []uintptr
+}
+
+func newStackError() *stackError {
+	var pcs = make([]uintptr, maxStackDepth)
+	return &stackError{pcs: pcs[:runtime.Callers(1, pcs)]}
+}
+
+func (e *stackError) Error() string {
+	return "with stack"
+}
+
+func (e *stackError) Callers() []uintptr {
+	return e.pcs
+}
+
+type frame uintptr
+
+type tracedError struct{ stackError }
+
+func (e *tracedError) StackTrace() []frame {
+	var frames = make([]frame, len(e.pcs))
+	for x, pc := range e.pcs {
+		frames[x] = frame(pc)
+	}
+	return frames
+}
+
+func TestRenderErrorChain(t *testing.T) {
+	var base = errors.New("base")
+	var joined = errors.Join(fmt.Errorf("first: %w", base), errors.New("second"))
+	var err = fmt.Errorf("outer: %w", joined)
+
+	var rendered = renderError(err, nil)
+	require.Equal(t, err.Error(), rendered[errorMessageKey])
+	require.Equal(t, "*fmt.wrapError", rendered[errorKindKey])
+	require.NotContains(t, rendered, errorStackKey)
+	var chain = rendered[errorChainKey].([]interface{})
+	var messages []string
+	for _, cause := range chain {
+		messages = append(messages, cause.(map[string]interface{})[errorMessageKey].(string))
+	}
+	require.Equal(t, []string{joined.Error(), "first: base", "base", "second"}, messages)
+	require.Equal(t, "*errors.joinError", chain[0].(map[string]interface{})[errorKindKey])
+}
+
+func TestRenderErrorStack(t *testing.T) {
+	var rendered = renderError(fmt.Errorf("wrapped: %w", newStackError()), nil)
+	var stack = rendered[errorStackKey].([]string)
+	require.Contains(t, stack[0], "newStackError")
+	require.Contains(t, stack[0], "errors_test.go:")
+
+	var traced = &tracedError{*newStackError()}
+	require.Equal(t, formatStack(traced.pcs), renderError(traced, nil)[errorStackKey])
+}
+
+func TestErrorStackConfig(t *testing.T) {
+	var buff = &bytes.Buffer{}
+	var logger = New(Config{Output: buff, ErrorStack: true})
+
+	logger.Warn(eventWithError{Err: errors.New("warning")})
+	var rendered = lastLine(t, buff)["error"].(map[string]interface{})
+	require.Equal(t, "warning", rendered[errorMessageKey])
+	require.NotContains(t, rendered, errorStackKey)
+
+	logger.Error(eventWithError{Err: errors.New("captured")})
+	rendered = lastLine(t, buff)["error"].(map[string]interface{})
+	var stack = rendered[errorStackKey].([]interface{})
+	require.True(t, strings.HasPrefix(stack[0].(string), "github.com/asecurityteam/logevent/v2.TestErrorStackConfig "), stack[0])
+
+	logger.Error(errors.New("plain"))
+	var line = lastLine(t, buff)
+	require.Equal(t, "plain", line["message"])
+	require.Contains(t, line["error"], errorStackKey)
+}
+
+func TestRenderNilError(t *testing.T) {
+	var typed *stackError
+	var _, annotations = Render(eventWithError{Err: typed})
+	require.Nil(t, annotations["error"])
+	_, annotations = Render(eventWithError{})
+	require.Nil(t, annotations["error"])
+}
@@ -0,0 +1,90 @@
+package logevent
+
+import (
+	"context"
+	"sync"
+)
+
+// ContextExtractor returns the fields that Ctx adds to the logger of a
+// context. It may return nil if the context carries nothing of interest.
+type ContextExtractor func(ctx context.Context) map[string]interface{}
+
+var (
+	extractorsLock sync.RWMutex
+	// extractors always includes the transaction id and trace context.
+	extractors = []ContextExtractor{transactionIDExtractor, traceContextExtractor}
+)
+
+// RegisterExtractor adds an extractor that is used by Ctx for every
+// logger. It is intended to be called during initialization.
+func RegisterExtractor(extractor ContextExtractor) {
+	extractorsLock.Lock()
+	defer extractorsLock.Unlock()
+	extractors = append(extractors, extractor)
+}
+
+// ContextValueExtractor creates an extractor that sets the field to the
+// value stored in a context under the key. Nothing is set if the context
+// has no value for the key.
+func ContextValueExtractor(key interface{}, field string) ContextExtractor {
+	return func(ctx context.Context) map[string]interface{} {
+		var value = ctx.Value(key)
+		if value == nil {
+			return nil
+		}
+		return map[string]interface{}{field: value}
+	}
+}
+
+// Ctx returns the logger of the context, as with FromContext, with the
+// fields of every registered extractor and every extractor in
+// Config.Extractors added. The logger in the context is not changed.
+//
+//	logevent.Ctx(ctx).Info(myEvent{})
+func Ctx(ctx context.Context) Logger {
+	var log = FromContext(ctx)
+	if ctx == nil {
+		return log
+	}
+	extractorsLock.RLock()
+	var global = extractors
+	extractorsLock.RUnlock()
+	var fields = extractFields(ctx, nil, global)
+	if l, ok := log.(*logger); ok {
+		fields = extractFields(ctx, fields, l.c.Extractors)
+	}
+	if len(fields) == 0 {
+		return log
+	}
+	return log.WithFields(fields)
+}
+
+func extractFields(ctx context.Context, fields map[string]interface{}, from []ContextExtractor) map[string]interface{} {
+	for _, extractor := range from {
+		for name, value := range extractor(ctx) {
+			if fields == nil {
+				fields = make(map[string]interface{})
+			}
+			fields[name] = value
+		}
+	}
+	return fields
+}
+
+func transactionIDExtractor(ctx context.Context) map[string]interface{} {
+	if id := GetTransactionID(ctx); id != "" {
+		return map[string]interface{}{TransactionIDKey: id}
+	}
+	return nil
+}
+
+func traceContextExtractor(ctx context.Context) map[string]interface{} {
+	if tc, ok := GetTraceContext(ctx); ok {
+		return map[string]interface{}{
+			TraceIDKey:    tc.TraceID,
+			SpanIDKey:     tc.SpanID,
+			TraceFlagsKey: tc.Flags(),
+		}
+	}
+	return nil
+}
@@ -0,0 +1,56 @@
+package logevent
+
+import (
+	"bytes"
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+type tenantKey struct{}
+
+func TestCtx(t *testing.T) {
+	var buff = &bytes.Buffer{}
+	var base = New(Config{
+		Output:     buff,
+		Extractors: []ContextExtractor{ContextValueExtractor(tenantKey{}, "tenant_id")},
+	})
+	var ctx = NewContext(context.Background(), base)
+
+	Ctx(ctx).Info("bare")
+	var line = lastLine(t, buff)
+	require.NotContains(t, line, TransactionIDKey)
+	require.NotContains(t, line, "tenant_id")
+
+	ctx = context.WithValue(ctx, transactionIDContextKey, "txid")
+	ctx = context.WithValue(ctx, traceContextKey, TraceContext{TraceID: "trace", SpanID: "span"})
+	ctx = context.WithValue(ctx, tenantKey{}, "acme")
+	Ctx(ctx).Info("enriched")
+	line = lastLine(t, buff)
+	require.Equal(t, "txid", line[TransactionIDKey])
+	require.Equal(t, "trace", line[TraceIDKey])
+	require.Equal(t, "span", line[SpanIDKey])
+	require.Equal(t, "acme", line["tenant_id"])
+
+	base.Info("unchanged")
+	require.NotContains(t, lastLine(t, buff), "tenant_id")
+}
+
+func TestRegisterExtractor(t *testing.T) {
+	extractorsLock.RLock()
+	var original = extractors
+	extractorsLock.RUnlock()
+	defer func() {
+		extractorsLock.Lock()
+		extractors = original
+		extractorsLock.Unlock()
+	}()
+	RegisterExtractor(ContextValueExtractor(tenantKey{}, "user_id"))
+
+	var buff = &bytes.Buffer{}
+	var ctx = NewContext(context.Background(), New(Config{Output: buff, RedactFields: []string{"user_id"}}))
+	ctx = context.WithValue(ctx, tenantKey{}, "alice")
+	Ctx(ctx).Info("registered")
+	require.Equal(t, Redacted, lastLine(t, buff)["user_id"])
+}
@@ -0,0 +1,88 @@
+package logevent
+
+import "fmt"
+
+// maxFieldDepth is the length of a fieldSet chain after which it is
+// collapsed in to a single set so that writes stay cheap.
+const maxFieldDepth = 16
+
+// badKey is used for a value passed to With without a key.
+const badKey = "!BADKEY"
+
+// fieldSet is an immutable set of logger fields. Each set adds to, and
+// overrides, the fields of its parent so that child loggers share the
+// fields of their parent without copying them.
+type fieldSet struct {
+	parent *fieldSet
+	fields map[string]interface{}
+	depth  int
+}
+
+// with returns a set that adds the fields to s, which may be nil.
+func (s *fieldSet) with(fields map[string]interface{}) *fieldSet {
+	if len(fields) == 0 {
+		return s
+	}
+	var next = &fieldSet{parent: s, fields: fields, depth: 1}
+	if s != nil {
+		next.depth = s.depth + 1
+	}
+	if next.depth > maxFieldDepth {
+		return &fieldSet{fields: next.flatten(), depth: 1}
+	}
+	return next
+}
+
+// flatten collects every field in to a single map.
+func (s *fieldSet) flatten() map[string]interface{} {
+	var fields = make(map[string]interface{})
+	s.apply(fields)
+	return fields
+}
+
+// apply adds the fields to the annotations without overriding those that
+// are already present.
+func (s *fieldSet) apply(annotations map[string]interface{}) {
+	for ; s != nil; s = s.parent {
+		for key, value := range s.fields {
+			addIfNotExists(annotations, key, value)
+		}
+	}
+}
+
+// pairsToFields converts alternating keys and values in to a map. Keys
+// that are not strings are formatted with fmt. A final key without a value
+// is recorded as a value of badKey, as with log/slog.
+func pairsToFields(keysAndValues []interface{}) map[string]interface{} {
+	var fields = make(map[string]interface{}, len(keysAndValues)/2)
+	for x := 0; x < len(keysAndValues); x = x + 2 {
+		if x+1 == len(keysAndValues) {
+			fields[badKey] = keysAndValues[x]
+			break
+		}
+		var key, ok = keysAndValues[x].(string)
+		if !ok {
+			key = fmt.Sprint(keysAndValues[x])
+		}
+		fields[key] = keysAndValues[x+1]
+	}
+	return fields
+}
+
+// With returns a copy of the logger with the fields added. The fields are
+// given as alternating keys and values. The logger is not changed.
+func (log *logger) With(keysAndValues ...interface{}) Logger {
+	return log.WithFields(pairsToFields(keysAndValues))
+}
+
+// WithFields returns a copy of the logger with the fields added. The
+// logger is not changed.
+func (log *logger) WithFields(fields map[string]interface{}) Logger {
+	var applied = make(map[string]interface{}, len(fields))
+	for name, value := range fields {
+		applied[name] = log.policy.apply(name, value)
+	}
+	var child = log.copy()
+	child.fields.Store(log.fields.Load().with(applied))
+	return child
+}
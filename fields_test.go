@@ -0,0 +1,82 @@
+package logevent
+
+import (
+	"bytes"
+	"encoding/json"
+	"strconv"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func lastLine(t *testing.T, buff *bytes.Buffer) map[string]interface{} {
+	var lines = bytes.Split(bytes.TrimSpace(buff.Bytes()), []byte("\n"))
+	var line = make(map[string]interface{})
+	require.NoError(t, json.Unmarshal(lines[len(lines)-1], &line))
+	return line
+}
+
+func TestWith(t *testing.T) {
+	var buff = &bytes.Buffer{}
+	var parent = New(Config{Output: buff, RedactFields: []string{"password"}})
+	parent.SetField("service", "billing")
+	var child = parent.With("user", "alice", "password", "secret", 42, "answer", "dangling")
+	parent.SetField("later", true)
+
+	child.Info("child")
+	var line = lastLine(t, buff)
+	require.Equal(t, "billing", line["service"])
+	require.Equal(t, "alice", line["user"])
+	require.Equal(t, Redacted, line["password"])
+	require.Equal(t, "answer", line["42"])
+	require.Equal(t, "dangling", line[badKey])
+	require.NotContains(t, line, "later")
+
+	parent.Info("parent")
+	line = lastLine(t, buff)
+	require.NotContains(t, line, "user")
+	require.Equal(t, true, line["later"])
+
+	var grandchild = child.WithFields(map[string]interface{}{"user": "bob"})
+	grandchild.Info("grandchild")
+	require.Equal(t, "bob", lastLine(t, buff)["user"])
+	child.Info("child again")
+	require.Equal(t, "alice", lastLine(t, buff)["user"])
+}
+
+func TestFieldSetCollapse(t *testing.T) {
+	var buff = &bytes.Buffer{}
+	var l = New(Config{Output: buff}).(*logger)
+	for x := 0; x < maxFieldDepth*2; x = x + 1 {
+		l.SetField("count", x)
+		l.SetField("key"+strconv.Itoa(x), x)
+	}
+	require.LessOrEqual(t, l.fields.Load().depth, maxFieldDepth)
+	l.Info("collapsed")
+	var line = lastLine(t, buff)
+	require.Equal(t, float64(maxFieldDepth*2-1), line["count"])
+	require.Equal(t, 0.0, line["key0"])
+}
+
+func TestSetFieldConcurrent(t *testing.T) {
+	var buff = &bytes.Buffer{}
+	var logger = New(Config{Output: buff})
+	var wg sync.WaitGroup
+	for x := 0; x < 8; x = x + 1 {
+		wg.Add(1)
+		go func(x int) {
+			defer wg.Done()
+			for y := 0; y < 50; y = y + 1 {
+				logger.SetField("key"+strconv.Itoa(x), y)
+				_ = logger.With("other", y)
+			}
+		}(x)
+	}
+	wg.Wait()
+	logger.Info("done")
+	var line = lastLine(t, buff)
+	for x := 0; x < 8; x = x + 1 {
+		require.Equal(t, 49.0, line["key"+strconv.Itoa(x)])
+	}
+}
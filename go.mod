@@ -3,12 +3,12 @@ module github.com/asecurityteam/logevent/v2
 go 1.22
 
 require (
-	github.com/fatih/structs v1.1.0
 	github.com/golang/mock v1.6.0
 	github.com/google/uuid v1.6.0
 	github.com/rs/xlog v0.0.0-20171227185259-131980fab91b
 	github.com/rs/zerolog v1.34.0
 	github.com/stretchr/testify v1.10.0
+	gopkg.in/yaml.v3 v3.0.1
 )
 
 require (
@@ -22,5 +22,4 @@ require (
 	github.com/rs/xid v1.6.0 // indirect
 	golang.org/x/net v0.0.0-20210405180319-a5a99cb37ef4 // indirect
 	golang.org/x/sys v0.24.0 // indirect
-	gopkg.in/yaml.v3 v3.0.1 // indirect
 )
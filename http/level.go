@@ -0,0 +1,116 @@
+package http
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/asecurityteam/logevent/v2"
+)
+
+// maxLevelRequestSize limits the body of a PUT to the LevelHandler.
+const maxLevelRequestSize = 1024
+
+// LevelRequest is the body of a PUT to the LevelHandler. It is also the
+// body returned by a GET.
+type LevelRequest struct {
+	// Level is the name of the level such as DEBUG.
+	Level string `json:"level"`
+	// TTL, if set on a PUT, is a duration such as "15m" after which the
+	// previous level is restored.
+	TTL string `json:"ttl,omitempty"`
+}
+
+// LevelHandler exposes a logevent.LevelVar over HTTP. A GET returns the
+// current level and a PUT changes it. A PUT with a body larger than 1KiB is
+// rejected with 413 Request Entity Too Large.
+type LevelHandler struct {
+	level  *logevent.LevelVar
+	lock   sync.Mutex
+	timer  *time.Timer
+	revert string
+}
+
+// NewLevelHandler creates a LevelHandler for the given LevelVar. Use
+// logevent.GetLevelVar to find the LevelVar of a Logger.
+func NewLevelHandler(level *logevent.LevelVar) *LevelHandler {
+	return &LevelHandler{level: level}
+}
+
+func (h *LevelHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet:
+		writeLevel(w, http.StatusOK, LevelRequest{Level: h.level.Level()})
+	case http.MethodPut:
+		var body LevelRequest
+		if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, maxLevelRequestSize)).Decode(&body); err != nil {
+			var tooLarge *http.MaxBytesError
+			if errors.As(err, &tooLarge) {
+				http.Error(w, err.Error(), http.StatusRequestEntityTooLarge)
+				return
+			}
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		var ttl time.Duration
+		if body.TTL != "" {
+			var err error
+			ttl, err = time.ParseDuration(body.TTL)
+			if err != nil || ttl <= 0 {
+				http.Error(w, "invalid ttl", http.StatusBadRequest)
+				return
+			}
+		}
+		if err := h.setLevel(body.Level, ttl); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		body.Level = h.level.Level()
+		writeLevel(w, http.StatusOK, body)
+	default:
+		w.Header().Set("Allow", http.MethodGet+", "+http.MethodPut)
+		http.Error(w, http.StatusText(http.StatusMethodNotAllowed), http.StatusMethodNotAllowed)
+	}
+}
+
+// setLevel changes the level and schedules the revert, if any. A change
+// replaces any revert scheduled by an earlier change, but a temporary
+// change made while another is active still reverts to the original level.
+func (h *LevelHandler) setLevel(level string, ttl time.Duration) error {
+	h.lock.Lock()
+	defer h.lock.Unlock()
+	var previous = h.level.Level()
+	if h.timer != nil {
+		previous = h.revert
+	}
+	if err := h.level.SetLevel(level); err != nil {
+		return err
+	}
+	if h.timer != nil {
+		h.timer.Stop()
+		h.timer = nil
+	}
+	if ttl > 0 {
+		var timer *time.Timer
+		timer = time.AfterFunc(ttl, func() {
+			h.lock.Lock()
+			defer h.lock.Unlock()
+			if h.timer != timer {
+				return
+			}
+			_ = h.level.SetLevel(previous)
+			h.timer = nil
+		})
+		h.timer = timer
+		h.revert = previous
+	}
+	return nil
+}
+
+func writeLevel(w http.ResponseWriter, status int, body LevelRequest) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(body)
+}
@@ -0,0 +1,77 @@
+package http
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/asecurityteam/logevent/v2"
+)
+
+func serveLevel(h http.Handler, method string, body string) *httptest.ResponseRecorder {
+	var w = httptest.NewRecorder()
+	h.ServeHTTP(w, httptest.NewRequest(method, "/level", strings.NewReader(body)))
+	return w
+}
+
+func TestLevelHandler(t *testing.T) {
+	var level = logevent.NewLevelVar("INFO")
+	var h = NewLevelHandler(level)
+
+	var w = serveLevel(h, http.MethodGet, "")
+	assert.Equal(t, http.StatusOK, w.Code)
+	var body LevelRequest
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &body))
+	assert.Equal(t, LevelRequest{Level: "INFO"}, body)
+
+	w = serveLevel(h, http.MethodPut, `{"level":"debug"}`)
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "DEBUG", level.Level())
+
+	for _, invalid := range []string{`{"level":"loud"}`, `{"level":"warn","ttl":"soon"}`, `{"level":"warn","ttl":"-1s"}`, `not json`} {
+		w = serveLevel(h, http.MethodPut, invalid)
+		assert.Equal(t, http.StatusBadRequest, w.Code, invalid)
+		assert.Equal(t, "DEBUG", level.Level())
+	}
+
+	w = serveLevel(h, http.MethodPut, `{"level":"warn","ttl":"`+strings.Repeat("1", maxLevelRequestSize)+`s"}`)
+	assert.Equal(t, http.StatusRequestEntityTooLarge, w.Code)
+	assert.Equal(t, "DEBUG", level.Level())
+
+	w = serveLevel(h, http.MethodDelete, "")
+	assert.Equal(t, http.StatusMethodNotAllowed, w.Code)
+	assert.Equal(t, "GET, PUT", w.Header().Get("Allow"))
+}
+
+func TestLevelHandlerTTL(t *testing.T) {
+	var level = logevent.NewLevelVar("WARN")
+	var h = NewLevelHandler(level)
+
+	var w = serveLevel(h, http.MethodPut, `{"level":"INFO","ttl":"1h"}`)
+	require.Equal(t, http.StatusOK, w.Code)
+	w = serveLevel(h, http.MethodPut, `{"level":"DEBUG","ttl":"20ms"}`)
+	require.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "DEBUG", level.Level())
+	assert.Eventually(t, func() bool { return level.Level() == "WARN" }, time.Second, 5*time.Millisecond)
+
+	// a permanent change cancels the revert
+	serveLevel(h, http.MethodPut, `{"level":"ERROR","ttl":"20ms"}`)
+	serveLevel(h, http.MethodPut, `{"level":"INFO"}`)
+	time.Sleep(50 * time.Millisecond)
+	assert.Equal(t, "INFO", level.Level())
+}
+
+func TestLevelHandlerLogger(t *testing.T) {
+	var recorder = &strings.Builder{}
+	var logger = logevent.New(logevent.Config{Level: "ERROR", Output: recorder})
+	var copied = logger.Copy()
+	serveLevel(NewLevelHandler(logevent.GetLevelVar(logger)), http.MethodPut, `{"level":"INFO"}`)
+	copied.Info("visible")
+	assert.Contains(t, recorder.String(), "visible")
+}
@@ -2,27 +2,200 @@ package http
 
 import (
 	"net/http"
+	"strings"
+	"time"
 
 	"github.com/asecurityteam/logevent/v2"
 )
 
+const (
+	// TransactionIDHeader is the default header used to propagate
+	// transaction IDs between services.
+	TransactionIDHeader = "X-Transaction-ID"
+	// RequestIDHeader is checked for a transaction ID when a request does
+	// not carry the transaction ID header.
+	RequestIDHeader = "X-Request-ID"
+
+	maxTransactionIDLength = 128
+)
+
+// RequestCompleted is the event emitted by the access log once a request
+// has been served.
+type RequestCompleted struct {
+	Method          string            `logevent:"method"`
+	Path            string            `logevent:"path"`
+	Route           string            `logevent:"route,omitempty"`
+	Status          int               `logevent:"status"`
+	BytesWritten    int64             `logevent:"bytes_written"`
+	DurationMS      float64           `logevent:"duration_ms"`
+	RemoteAddr      string            `logevent:"remote_addr"`
+	RequestHeaders  map[string]string `logevent:"request_headers,omitempty"`
+	ResponseHeaders map[string]string `logevent:"response_headers,omitempty"`
+	Message         string            `logevent:"message,default=request-completed"`
+}
+
 // Middleware wraps an http.Handler and injects a logevent.Logger in to the
 // context.
 type Middleware struct {
-	logger  logevent.Logger
-	wrapped http.Handler
+	logger          logevent.Logger
+	wrapped         http.Handler
+	accessLog       bool
+	route           func(*http.Request) string
+	requestHeaders  []string
+	responseHeaders []string
+	txHeader        string
+}
+
+// MiddlewareOption configures optional behavior of the Middleware.
+type MiddlewareOption func(*Middleware)
+
+// WithAccessLog enables logging a RequestCompleted event for every request.
+// The event is logged at ERROR for 5xx responses, WARN for 4xx responses,
+// and INFO otherwise. A handler that panics before writing a status is
+// logged with status 500 and the panic is then allowed to continue.
+func WithAccessLog() MiddlewareOption {
+	return func(m *Middleware) {
+		m.accessLog = true
+	}
+}
+
+// WithRoute sets the function used to name the route of a request in the
+// access log. Use MuxRoute to name routes by their http.ServeMux pattern.
+func WithRoute(route func(*http.Request) string) MiddlewareOption {
+	return func(m *Middleware) {
+		m.route = route
+	}
+}
+
+// WithRequestHeaders lists the request headers included in the access log.
+// No headers are included by default.
+func WithRequestHeaders(names ...string) MiddlewareOption {
+	return func(m *Middleware) {
+		m.requestHeaders = append(m.requestHeaders, names...)
+	}
+}
+
+// WithResponseHeaders lists the response headers included in the access
+// log. No headers are included by default.
+func WithResponseHeaders(names ...string) MiddlewareOption {
+	return func(m *Middleware) {
+		m.responseHeaders = append(m.responseHeaders, names...)
+	}
+}
+
+// WithTransactionHeader sets the header from which the transaction ID of
+// a request is read and on which it is returned in the response. The
+// default is TransactionIDHeader.
+func WithTransactionHeader(name string) MiddlewareOption {
+	return func(m *Middleware) {
+		m.txHeader = name
+	}
+}
+
+// MuxRoute names the route of a request with the pattern that it matches
+// in the http.ServeMux.
+func MuxRoute(mux *http.ServeMux) func(*http.Request) string {
+	return func(r *http.Request) string {
+		var _, pattern = mux.Handler(r)
+		return pattern
+	}
 }
 
 // NewMiddleware generates an HTTP middleware with the given options set.
-func NewMiddleware(logger logevent.Logger) func(http.Handler) http.Handler {
+func NewMiddleware(logger logevent.Logger, options ...MiddlewareOption) func(http.Handler) http.Handler {
 	return func(wrapped http.Handler) http.Handler {
-		return &Middleware{wrapped: wrapped, logger: logger}
+		var m = &Middleware{wrapped: wrapped, logger: logger, txHeader: TransactionIDHeader}
+		for _, option := range options {
+			option(m)
+		}
+		return m
 	}
 }
 
 func (m *Middleware) ServeHTTP(w http.ResponseWriter, r *http.Request) {
-	r = r.WithContext(logevent.NewContext(r.Context(), m.logger.Copy()))
-	m.wrapped.ServeHTTP(w, r)
+	var ctx, logger = logevent.WithTransactionID(r.Context(), m.logger, m.transactionID(r))
+	w.Header().Set(m.txHeader, logevent.GetTransactionID(ctx))
+	ctx, logger = logevent.WithTraceContext(ctx, logger, traceContextFromHeaders(
+		r.Header.Get(TraceparentHeader), r.Header.Get(TracestateHeader),
+	))
+	r = r.WithContext(ctx)
+	if !m.accessLog {
+		m.wrapped.ServeHTTP(w, r)
+		return
+	}
+	var start = time.Now()
+	var recorder = &responseRecorder{ResponseWriter: w}
+	var completed bool
+	// the access log is written even if the handler panics, in which case
+	// the panic continues once it has been logged
+	defer func() {
+		var status = recorder.statusCode()
+		if !completed && recorder.status == 0 && !recorder.hijacked {
+			status = http.StatusInternalServerError
+		}
+		m.logRequest(logger, r, recorder, status, start)
+	}()
+	m.wrapped.ServeHTTP(recorder, r)
+	completed = true
+}
+
+// logRequest emits the RequestCompleted event for a request.
+func (m *Middleware) logRequest(logger logevent.Logger, r *http.Request, recorder *responseRecorder, status int, start time.Time) {
+	var event = RequestCompleted{
+		Method:          r.Method,
+		Path:            r.URL.Path,
+		Status:          status,
+		BytesWritten:    recorder.written,
+		DurationMS:      float64(time.Since(start)) / float64(time.Millisecond),
+		RemoteAddr:      r.RemoteAddr,
+		RequestHeaders:  selectHeaders(r.Header, m.requestHeaders),
+		ResponseHeaders: selectHeaders(recorder.Header(), m.responseHeaders),
+	}
+	if m.route != nil {
+		event.Route = m.route(r)
+	}
+	switch {
+	case event.Status >= http.StatusInternalServerError:
+		logger.Error(event)
+	case event.Status >= http.StatusBadRequest:
+		logger.Warn(event)
+	default:
+		logger.Info(event)
+	}
+}
+
+// transactionID returns the transaction ID sent by the client, or an empty
+// string if there is none or it is not safe to log.
+func (m *Middleware) transactionID(r *http.Request) string {
+	var id = r.Header.Get(m.txHeader)
+	if id == "" {
+		id = r.Header.Get(RequestIDHeader)
+	}
+	if len(id) > maxTransactionIDLength || strings.IndexFunc(id, invalidTransactionIDRune) >= 0 {
+		return ""
+	}
+	return id
+}
+
+func invalidTransactionIDRune(r rune) bool {
+	return r < '!' || r > '~'
+}
+
+// selectHeaders copies the allowed headers that are present. Multiple
+// values of a header are joined with a comma.
+func selectHeaders(h http.Header, names []string) map[string]string {
+	if len(names) == 0 {
+		return nil
+	}
+	var selected = make(map[string]string, len(names))
+	for _, name := range names {
+		var values = h.Values(name)
+		if len(values) == 0 {
+			continue
+		}
+		selected[http.CanonicalHeaderKey(name)] = strings.Join(values, ",")
+	}
+	return selected
 }
 
 // FromRequest is a helper for extracting the logger from an *http.Request.
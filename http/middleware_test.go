@@ -6,7 +6,11 @@ import (
 	"net/http/httptest"
 	"testing"
 
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
 	"github.com/asecurityteam/logevent/v2"
+	"github.com/asecurityteam/logevent/v2/logtest"
 )
 
 type fixtureHandler struct {
@@ -29,3 +33,161 @@ func TestMiddleware(t *testing.T) {
 		t.Error("did not find Logger in context")
 	}
 }
+
+func TestMiddlewareAccessLog(t *testing.T) {
+	var cases = []struct {
+		name   string
+		status int
+		level  string
+	}{
+		{name: "ok", status: http.StatusOK, level: logtest.LevelInfo},
+		{name: "client error", status: http.StatusNotFound, level: logtest.LevelWarn},
+		{name: "server error", status: http.StatusBadGateway, level: logtest.LevelError},
+	}
+	for _, currentCase := range cases {
+		t.Run(currentCase.name, func(t *testing.T) {
+			var recorder = logtest.New()
+			var mux = http.NewServeMux()
+			mux.HandleFunc("/users/", func(w http.ResponseWriter, r *http.Request) {
+				FromRequest(r).SetField("handled", true)
+				w.Header().Set("Content-Type", "text/plain")
+				w.Header().Set("X-Secret", "hidden")
+				w.WriteHeader(currentCase.status)
+				_, _ = w.Write([]byte("hello"))
+			})
+			var h = NewMiddleware(recorder,
+				WithAccessLog(),
+				WithRoute(MuxRoute(mux)),
+				WithRequestHeaders("user-agent", "X-Missing"),
+				WithResponseHeaders("Content-Type"),
+			)(mux)
+			var r = httptest.NewRequest(http.MethodPost, "/users/123", nil)
+			r.Header.Set("User-Agent", "test")
+			r.Header.Set("Authorization", "secret")
+			h.ServeHTTP(httptest.NewRecorder(), r)
+
+			var entries = recorder.OfType(RequestCompleted{})
+			require.Len(t, entries, 1)
+			var entry = entries[0]
+			var event = entry.Event.(RequestCompleted)
+			assert.Equal(t, currentCase.level, entry.Level)
+			assert.Equal(t, "request-completed", entry.Message)
+			assert.Equal(t, true, entry.Fields["handled"])
+			assert.Equal(t, http.MethodPost, event.Method)
+			assert.Equal(t, "/users/123", event.Path)
+			assert.Equal(t, "/users/", event.Route)
+			assert.Equal(t, currentCase.status, event.Status)
+			assert.Equal(t, int64(5), event.BytesWritten)
+			assert.Equal(t, r.RemoteAddr, event.RemoteAddr)
+			assert.Equal(t, map[string]string{"User-Agent": "test"}, event.RequestHeaders)
+			assert.Equal(t, map[string]string{"Content-Type": "text/plain"}, event.ResponseHeaders)
+		})
+	}
+}
+
+func TestMiddlewareAccessLogPanic(t *testing.T) {
+	var recorder = logtest.New()
+	var h = NewMiddleware(recorder, WithAccessLog())(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		panic("handler failed")
+	}))
+	require.PanicsWithValue(t, "handler failed", func() {
+		h.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/", nil))
+	})
+
+	var entries = recorder.OfType(RequestCompleted{})
+	require.Len(t, entries, 1)
+	assert.Equal(t, logtest.LevelError, entries[0].Level)
+	assert.Equal(t, http.StatusInternalServerError, entries[0].Event.(RequestCompleted).Status)
+
+	// a status that was already sent is logged as it is
+	recorder = logtest.New()
+	h = NewMiddleware(recorder, WithAccessLog())(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusAccepted)
+		panic(http.ErrAbortHandler)
+	}))
+	require.Panics(t, func() {
+		h.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/", nil))
+	})
+	entries = recorder.OfType(RequestCompleted{})
+	require.Len(t, entries, 1)
+	assert.Equal(t, logtest.LevelInfo, entries[0].Level)
+	assert.Equal(t, http.StatusAccepted, entries[0].Event.(RequestCompleted).Status)
+}
+
+func TestMiddlewareWithoutAccessLog(t *testing.T) {
+	var recorder = logtest.New()
+	var h = NewMiddleware(recorder)(&fixtureHandler{})
+	h.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/", nil))
+	assert.Empty(t, recorder.Entries())
+}
+
+func TestMiddlewareTransactionID(t *testing.T) {
+	var cases = []struct {
+		name     string
+		header   string
+		headers  map[string]string
+		expected string
+	}{
+		{name: "transaction header", headers: map[string]string{TransactionIDHeader: "abcd", RequestIDHeader: "efgh"}, expected: "abcd"},
+		{name: "request header", headers: map[string]string{RequestIDHeader: "efgh"}, expected: "efgh"},
+		{name: "custom header", header: "X-Correlation-ID", headers: map[string]string{"X-Correlation-ID": "ijkl"}, expected: "ijkl"},
+		{name: "generated", headers: map[string]string{}},
+		{name: "unsafe", headers: map[string]string{TransactionIDHeader: "a\nb"}},
+	}
+	for _, currentCase := range cases {
+		t.Run(currentCase.name, func(t *testing.T) {
+			var recorder = logtest.New()
+			var txid string
+			var options []MiddlewareOption
+			var header = TransactionIDHeader
+			if currentCase.header != "" {
+				header = currentCase.header
+				options = append(options, WithTransactionHeader(header))
+			}
+			var h = NewMiddleware(recorder, options...)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				txid = logevent.GetTransactionID(r.Context())
+				FromRequest(r).Info("handled")
+			}))
+			var r = httptest.NewRequest(http.MethodGet, "/", nil)
+			for key, value := range currentCase.headers {
+				r.Header.Set(key, value)
+			}
+			var w = httptest.NewRecorder()
+			h.ServeHTTP(w, r)
+
+			if currentCase.expected != "" {
+				assert.Equal(t, currentCase.expected, txid)
+			} else {
+				assert.NotEmpty(t, txid)
+				assert.NotContains(t, txid, "\n")
+			}
+			assert.Equal(t, txid, w.Header().Get(header))
+			assert.Equal(t, txid, recorder.Entries()[0].Fields[logevent.TransactionIDKey])
+		})
+	}
+}
+
+func TestMiddlewareTraceContext(t *testing.T) {
+	var recorder = logtest.New()
+	var tc logevent.TraceContext
+	var h = NewMiddleware(recorder)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		tc, _ = logevent.GetTraceContext(r.Context())
+		FromRequest(r).Info("handled")
+	}))
+	var r = httptest.NewRequest(http.MethodGet, "/", nil)
+	r.Header.Set(TraceparentHeader, "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01")
+	r.Header.Set(TracestateHeader, "vendor=value")
+	h.ServeHTTP(httptest.NewRecorder(), r)
+
+	assert.Equal(t, "4bf92f3577b34da6a3ce929d0e0e4736", tc.TraceID)
+	assert.NotEqual(t, "00f067aa0ba902b7", tc.SpanID)
+	assert.Equal(t, "vendor=value", tc.TraceState)
+	var fields = recorder.Entries()[0].Fields
+	assert.Equal(t, tc.TraceID, fields[logevent.TraceIDKey])
+	assert.Equal(t, tc.SpanID, fields[logevent.SpanIDKey])
+	assert.Equal(t, "01", fields[logevent.TraceFlagsKey])
+}
+
+func TestRequestCompletedTags(t *testing.T) {
+	require.NoError(t, logevent.ValidateEvent(RequestCompleted{}))
+}
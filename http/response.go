@@ -0,0 +1,78 @@
+package http
+
+import (
+	"bufio"
+	"fmt"
+	"net"
+	"net/http"
+)
+
+// responseRecorder wraps an http.ResponseWriter to capture the status and
+// size of a response for the access log.
+type responseRecorder struct {
+	http.ResponseWriter
+	status   int
+	written  int64
+	hijacked bool
+}
+
+// WriteHeader records the final status code of the response. Informational
+// responses may be sent any number of times before the final status.
+func (w *responseRecorder) WriteHeader(code int) {
+	if w.status == 0 && code >= http.StatusOK {
+		w.status = code
+	}
+	w.ResponseWriter.WriteHeader(code)
+}
+
+func (w *responseRecorder) Write(b []byte) (int, error) {
+	if w.status == 0 {
+		w.status = http.StatusOK
+	}
+	var n, err = w.ResponseWriter.Write(b)
+	w.written = w.written + int64(n)
+	return n, err
+}
+
+// Flush passes through to the wrapped writer if it supports http.Flusher.
+func (w *responseRecorder) Flush() {
+	var f, ok = w.ResponseWriter.(http.Flusher)
+	if !ok {
+		return
+	}
+	if w.status == 0 {
+		w.status = http.StatusOK
+	}
+	f.Flush()
+}
+
+// Hijack passes through to the wrapped writer if it supports http.Hijacker.
+func (w *responseRecorder) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	var h, ok = w.ResponseWriter.(http.Hijacker)
+	if !ok {
+		return nil, nil, fmt.Errorf("%T does not implement http.Hijacker", w.ResponseWriter)
+	}
+	var conn, rw, err = h.Hijack()
+	if err == nil {
+		w.hijacked = true
+	}
+	return conn, rw, err
+}
+
+// Unwrap exposes the wrapped writer to http.ResponseController.
+func (w *responseRecorder) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
+
+// statusCode returns the status that was sent to the client.
+func (w *responseRecorder) statusCode() int {
+	switch {
+	case w.status != 0:
+		return w.status
+	case w.hijacked:
+		return http.StatusSwitchingProtocols
+	default:
+		// a handler that writes nothing results in an implicit 200
+		return http.StatusOK
+	}
+}
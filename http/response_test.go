@@ -0,0 +1,76 @@
+package http
+
+import (
+	"bufio"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type hijackRecorder struct {
+	*httptest.ResponseRecorder
+	hijacked bool
+}
+
+func (w *hijackRecorder) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	w.hijacked = true
+	return nil, nil, nil
+}
+
+// informationalRecorder accepts informational responses, which the
+// httptest.ResponseRecorder treats as final.
+type informationalRecorder struct {
+	*httptest.ResponseRecorder
+	informational []int
+}
+
+func (w *informationalRecorder) WriteHeader(code int) {
+	if code < http.StatusOK {
+		w.informational = append(w.informational, code)
+		return
+	}
+	w.ResponseRecorder.WriteHeader(code)
+}
+
+func TestResponseRecorder(t *testing.T) {
+	var w = &informationalRecorder{ResponseRecorder: httptest.NewRecorder()}
+	var recorder = &responseRecorder{ResponseWriter: w}
+	assert.Equal(t, http.StatusOK, recorder.statusCode())
+
+	recorder.WriteHeader(http.StatusEarlyHints)
+	recorder.WriteHeader(http.StatusCreated)
+	recorder.WriteHeader(http.StatusAccepted)
+	var n, err = recorder.Write([]byte("hello"))
+	assert.Nil(t, err)
+	assert.Equal(t, 5, n)
+	assert.Equal(t, http.StatusCreated, recorder.statusCode())
+	assert.Equal(t, int64(5), recorder.written)
+	assert.Equal(t, []int{http.StatusEarlyHints}, w.informational)
+	assert.Equal(t, w, recorder.Unwrap())
+}
+
+func TestResponseRecorderFlush(t *testing.T) {
+	var w = httptest.NewRecorder()
+	var recorder = &responseRecorder{ResponseWriter: w}
+	var _, ok = http.ResponseWriter(recorder).(http.Flusher)
+	assert.True(t, ok)
+	recorder.Flush()
+	assert.True(t, w.Flushed)
+	assert.Equal(t, http.StatusOK, recorder.statusCode())
+}
+
+func TestResponseRecorderHijack(t *testing.T) {
+	var recorder = &responseRecorder{ResponseWriter: httptest.NewRecorder()}
+	var _, _, err = recorder.Hijack()
+	assert.NotNil(t, err)
+
+	var w = &hijackRecorder{ResponseRecorder: httptest.NewRecorder()}
+	recorder = &responseRecorder{ResponseWriter: w}
+	_, _, err = recorder.Hijack()
+	assert.Nil(t, err)
+	assert.True(t, w.hijacked)
+	assert.Equal(t, http.StatusSwitchingProtocols, recorder.statusCode())
+}
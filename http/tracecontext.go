@@ -0,0 +1,123 @@
+package http
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"strings"
+
+	"github.com/asecurityteam/logevent/v2"
+)
+
+const (
+	// TraceparentHeader carries the W3C trace context of a request.
+	TraceparentHeader = "traceparent"
+	// TracestateHeader carries vendor specific trace state of a request.
+	TracestateHeader = "tracestate"
+
+	traceparentVersion = "00"
+	// version-traceid-spanid-flags
+	traceparentLength = 2 + 1 + 32 + 1 + 16 + 1 + 2
+	traceIDBytes      = 16
+	spanIDBytes       = 8
+)
+
+// ErrInvalidTraceparent is returned when a traceparent header can not be
+// parsed.
+var ErrInvalidTraceparent = errors.New("invalid traceparent")
+
+// ParseTraceparent parses the value of a W3C traceparent header. Headers
+// from future versions of the specification are accepted as long as they
+// begin with the fields defined by version 00.
+func ParseTraceparent(traceparent string) (logevent.TraceContext, error) {
+	var value = strings.TrimSpace(traceparent)
+	if len(value) < traceparentLength {
+		return logevent.TraceContext{}, ErrInvalidTraceparent
+	}
+	var version = value[0:2]
+	if !isLowerHex(version) || version == "ff" {
+		return logevent.TraceContext{}, ErrInvalidTraceparent
+	}
+	if version == traceparentVersion && len(value) != traceparentLength {
+		return logevent.TraceContext{}, ErrInvalidTraceparent
+	}
+	if len(value) > traceparentLength && value[traceparentLength] != '-' {
+		return logevent.TraceContext{}, ErrInvalidTraceparent
+	}
+	if value[2] != '-' || value[35] != '-' || value[52] != '-' {
+		return logevent.TraceContext{}, ErrInvalidTraceparent
+	}
+	var traceID, spanID, flags = value[3:35], value[36:52], value[53:55]
+	if !isLowerHex(traceID) || !isLowerHex(spanID) || !isLowerHex(flags) {
+		return logevent.TraceContext{}, ErrInvalidTraceparent
+	}
+	if isZero(traceID) || isZero(spanID) {
+		return logevent.TraceContext{}, ErrInvalidTraceparent
+	}
+	var flagBytes, _ = hex.DecodeString(flags)
+	return logevent.TraceContext{
+		TraceID:    traceID,
+		SpanID:     spanID,
+		TraceFlags: flagBytes[0],
+	}, nil
+}
+
+// FormatTraceparent renders the trace context as a version 00 traceparent
+// header value.
+func FormatTraceparent(tc logevent.TraceContext) string {
+	return traceparentVersion + "-" + tc.TraceID + "-" + tc.SpanID + "-" + tc.Flags()
+}
+
+// NewTraceContext starts a new, sampled trace with a random trace and
+// span id.
+func NewTraceContext() logevent.TraceContext {
+	return logevent.TraceContext{
+		TraceID:    randomHex(traceIDBytes),
+		SpanID:     randomHex(spanIDBytes),
+		TraceFlags: logevent.TraceFlagSampled,
+	}
+}
+
+// NewChildSpan starts a new span within the trace of the parent.
+func NewChildSpan(parent logevent.TraceContext) logevent.TraceContext {
+	var child = parent
+	child.SpanID = randomHex(spanIDBytes)
+	return child
+}
+
+// traceContextFromHeaders reads the trace context of an incoming request
+// and starts a span for it. A new trace is started if the request does not
+// carry a valid traceparent.
+func traceContextFromHeaders(traceparent string, tracestate string) logevent.TraceContext {
+	var parent, err = ParseTraceparent(traceparent)
+	if err != nil {
+		return NewTraceContext()
+	}
+	parent.TraceState = tracestate
+	return NewChildSpan(parent)
+}
+
+func randomHex(size int) string {
+	var b = make([]byte, size)
+	for {
+		// crypto/rand.Read never returns an error on supported platforms
+		_, _ = rand.Read(b)
+		var encoded = hex.EncodeToString(b)
+		if !isZero(encoded) {
+			return encoded
+		}
+	}
+}
+
+func isLowerHex(s string) bool {
+	for _, r := range s {
+		if (r < '0' || r > '9') && (r < 'a' || r > 'f') {
+			return false
+		}
+	}
+	return true
+}
+
+func isZero(s string) bool {
+	return strings.Trim(s, "0") == ""
+}
@@ -0,0 +1,90 @@
+package http
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/asecurityteam/logevent/v2"
+)
+
+func TestParseTraceparent(t *testing.T) {
+	var cases = []struct {
+		name     string
+		value    string
+		expected logevent.TraceContext
+		err      bool
+	}{
+		{
+			name:  "valid",
+			value: "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01",
+			expected: logevent.TraceContext{
+				TraceID:    "4bf92f3577b34da6a3ce929d0e0e4736",
+				SpanID:     "00f067aa0ba902b7",
+				TraceFlags: 1,
+			},
+		},
+		{
+			name:  "future version",
+			value: "01-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-00-extra",
+			expected: logevent.TraceContext{
+				TraceID: "4bf92f3577b34da6a3ce929d0e0e4736",
+				SpanID:  "00f067aa0ba902b7",
+			},
+		},
+		{name: "empty", value: "", err: true},
+		{name: "version 00 with extra", value: "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01-extra", err: true},
+		{name: "future version bad separator", value: "01-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01x", err: true},
+		{name: "forbidden version", value: "ff-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01", err: true},
+		{name: "upper case", value: "00-4BF92F3577B34DA6A3CE929D0E0E4736-00f067aa0ba902b7-01", err: true},
+		{name: "zero trace", value: "00-00000000000000000000000000000000-00f067aa0ba902b7-01", err: true},
+		{name: "zero span", value: "00-4bf92f3577b34da6a3ce929d0e0e4736-0000000000000000-01", err: true},
+		{name: "bad separator", value: "00_4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01", err: true},
+		{name: "bad flags", value: "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-0g", err: true},
+	}
+	for _, currentCase := range cases {
+		t.Run(currentCase.name, func(t *testing.T) {
+			var tc, err = ParseTraceparent(currentCase.value)
+			if currentCase.err {
+				assert.Equal(t, ErrInvalidTraceparent, err)
+				return
+			}
+			assert.Nil(t, err)
+			assert.Equal(t, currentCase.expected, tc)
+		})
+	}
+}
+
+func TestFormatTraceparent(t *testing.T) {
+	var value = "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01"
+	var tc, _ = ParseTraceparent(value)
+	assert.Equal(t, value, FormatTraceparent(tc))
+}
+
+func TestNewTraceContext(t *testing.T) {
+	var tc = NewTraceContext()
+	assert.Len(t, tc.TraceID, 32)
+	assert.Len(t, tc.SpanID, 16)
+	assert.True(t, tc.Sampled())
+	var parsed, err = ParseTraceparent(FormatTraceparent(tc))
+	assert.Nil(t, err)
+	assert.Equal(t, tc, parsed)
+
+	tc.TraceState = "vendor=value"
+	var child = NewChildSpan(tc)
+	assert.Equal(t, tc.TraceID, child.TraceID)
+	assert.Equal(t, tc.TraceState, child.TraceState)
+	assert.NotEqual(t, tc.SpanID, child.SpanID)
+}
+
+func TestTraceContextFromHeaders(t *testing.T) {
+	var tc = traceContextFromHeaders("00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-00", "vendor=value")
+	assert.Equal(t, "4bf92f3577b34da6a3ce929d0e0e4736", tc.TraceID)
+	assert.NotEqual(t, "00f067aa0ba902b7", tc.SpanID)
+	assert.Equal(t, "vendor=value", tc.TraceState)
+	assert.False(t, tc.Sampled())
+
+	tc = traceContextFromHeaders("invalid", "vendor=value")
+	assert.Len(t, tc.TraceID, 32)
+	assert.Empty(t, tc.TraceState)
+}
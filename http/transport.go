@@ -9,20 +9,62 @@ import (
 // Transport injects a `logevent.Logger` into the request context
 // during its `http.RoundTrip`.
 type Transport struct {
-	logger  logevent.Logger
-	wrapped http.RoundTripper
+	logger   logevent.Logger
+	wrapped  http.RoundTripper
+	txHeader string
 }
 
-// RoundTrip injects a `logevent.Logger` into the current request context.
+// TransportOption configures optional behavior of the Transport.
+type TransportOption func(*Transport)
+
+// WithOutboundTransactionHeader sets the header on which the transaction
+// ID of the request context is sent. The default is TransactionIDHeader.
+func WithOutboundTransactionHeader(name string) TransportOption {
+	return func(t *Transport) {
+		t.txHeader = name
+	}
+}
+
+// RoundTrip injects a `logevent.Logger` into the current request context
+// and sends the transaction ID of the context, if any, to the server. If
+// the context carries a trace then a child span is started for the call.
 func (t *Transport) RoundTrip(r *http.Request) (*http.Response, error) {
-	r = r.WithContext(logevent.NewContext(r.Context(), t.logger.Copy()))
+	var logger = t.logger.Copy()
+	var ctx = logevent.NewContext(r.Context(), logger)
+	var headers = make(map[string]string)
+	if id := logevent.GetTransactionID(ctx); id != "" {
+		ctx, logger = logevent.WithTransactionID(ctx, logger, id)
+		headers[t.txHeader] = id
+	}
+	if parent, ok := logevent.GetTraceContext(ctx); ok && r.Header.Get(TraceparentHeader) == "" {
+		var child = NewChildSpan(parent)
+		ctx, _ = logevent.WithTraceContext(ctx, logger, child)
+		headers[TraceparentHeader] = FormatTraceparent(child)
+		if child.TraceState != "" {
+			headers[TracestateHeader] = child.TraceState
+		}
+	}
+	// RoundTrippers must not modify the caller's request
+	r = r.Clone(ctx)
+	for name, value := range headers {
+		if r.Header == nil {
+			r.Header = make(http.Header)
+		}
+		if r.Header.Get(name) == "" {
+			r.Header.Set(name, value)
+		}
+	}
 	return t.wrapped.RoundTrip(r)
 }
 
 // NewTransport wraps a `transport.Decorator` in a new one that injects a
 // `logevent.Logger` into the context.
-func NewTransport(logger logevent.Logger) func(http.RoundTripper) http.RoundTripper {
+func NewTransport(logger logevent.Logger, options ...TransportOption) func(http.RoundTripper) http.RoundTripper {
 	return func(next http.RoundTripper) http.RoundTripper {
-		return &Transport{logger: logger, wrapped: next}
+		var t = &Transport{logger: logger, wrapped: next, txHeader: TransactionIDHeader}
+		for _, option := range options {
+			option(t)
+		}
+		return t
 	}
 }
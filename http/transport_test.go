@@ -2,6 +2,7 @@ package http
 
 import (
 	"bytes"
+	"context"
 	"io"
 	"net/http"
 	"net/http/httptest"
@@ -10,6 +11,7 @@ import (
 	"github.com/stretchr/testify/assert"
 
 	"github.com/asecurityteam/logevent/v2"
+	"github.com/asecurityteam/logevent/v2/logtest"
 )
 
 type instanceStoreTransport struct {
@@ -31,3 +33,73 @@ func TestNewTransport(t *testing.T) {
 	_, _ = transport.RoundTrip(httptest.NewRequest(http.MethodGet, "/", nil))
 	assert.NotNil(t, wrapped.instance)
 }
+
+type headerStoreTransport struct {
+	request *http.Request
+}
+
+func (t *headerStoreTransport) RoundTrip(r *http.Request) (*http.Response, error) {
+	t.request = r
+	FromRequest(r).Info("outbound")
+	return &http.Response{
+		StatusCode: 200,
+		Body:       io.NopCloser(bytes.NewBufferString(``)),
+	}, nil
+}
+
+func TestTransportTransactionID(t *testing.T) {
+	var recorder = logtest.New()
+	var wrapped = &headerStoreTransport{}
+	var transport = NewTransport(recorder)(wrapped)
+	var logger logevent.Logger = logtest.New()
+	var ctx = logevent.SetTransactionID(context.Background(), &logger, "abcd")
+	var r = httptest.NewRequest(http.MethodGet, "/", nil).WithContext(ctx)
+	_, _ = transport.RoundTrip(r)
+	assert.Equal(t, "abcd", wrapped.request.Header.Get(TransactionIDHeader))
+	assert.Empty(t, r.Header.Get(TransactionIDHeader), "caller request was modified")
+	assert.Equal(t, "abcd", recorder.Entries()[0].Fields[logevent.TransactionIDKey])
+}
+
+func TestTransportTransactionIDCustomHeader(t *testing.T) {
+	var wrapped = &headerStoreTransport{}
+	var transport = NewTransport(logtest.New(), WithOutboundTransactionHeader("X-Trace"))(wrapped)
+	var logger logevent.Logger = logtest.New()
+	var ctx = logevent.SetTransactionID(context.Background(), &logger, "abcd")
+	var r = httptest.NewRequest(http.MethodGet, "/", nil).WithContext(ctx)
+	r.Header = nil
+	_, _ = transport.RoundTrip(r)
+	assert.Equal(t, "abcd", wrapped.request.Header.Get("X-Trace"))
+	assert.Empty(t, wrapped.request.Header.Get(TransactionIDHeader))
+}
+
+func TestTransportNoTransactionID(t *testing.T) {
+	var wrapped = &headerStoreTransport{}
+	var transport = NewTransport(logtest.New())(wrapped)
+	var r = httptest.NewRequest(http.MethodGet, "/", nil)
+	_, _ = transport.RoundTrip(r)
+	assert.Empty(t, wrapped.request.Header.Get(TransactionIDHeader))
+}
+
+func TestTransportTraceContext(t *testing.T) {
+	var recorder = logtest.New()
+	var wrapped = &headerStoreTransport{}
+	var transport = NewTransport(recorder)(wrapped)
+	var parent = NewTraceContext()
+	parent.TraceState = "vendor=value"
+	var ctx = logevent.SetTraceContext(context.Background(), logtest.New(), parent)
+	_, _ = transport.RoundTrip(httptest.NewRequest(http.MethodGet, "/", nil).WithContext(ctx))
+
+	var child, err = ParseTraceparent(wrapped.request.Header.Get(TraceparentHeader))
+	assert.Nil(t, err)
+	assert.Equal(t, parent.TraceID, child.TraceID)
+	assert.NotEqual(t, parent.SpanID, child.SpanID)
+	assert.Equal(t, "vendor=value", wrapped.request.Header.Get(TracestateHeader))
+	var fields = recorder.Entries()[0].Fields
+	assert.Equal(t, child.SpanID, fields[logevent.SpanIDKey])
+
+	var explicit = "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01"
+	var r = httptest.NewRequest(http.MethodGet, "/", nil).WithContext(ctx)
+	r.Header.Set(TraceparentHeader, explicit)
+	_, _ = transport.RoundTrip(r)
+	assert.Equal(t, explicit, wrapped.request.Header.Get(TraceparentHeader))
+}
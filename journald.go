@@ -0,0 +1,138 @@
+package logevent
+
+import (
+	"bytes"
+	"encoding/binary"
+	"net"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// JournalSocket is the path of the systemd-journald native protocol socket.
+const JournalSocket = "/run/systemd/journal/socket"
+
+const journalMaxKey = 64
+
+// journalReserved are the fields written by the journald encoder itself.
+// Annotations with the same names are dropped rather than sending a second
+// value for the field.
+var journalReserved = map[string]bool{
+	"MESSAGE":           true,
+	"PRIORITY":          true,
+	"SYSLOG_IDENTIFIER": true,
+	"CODE_FILE":         true,
+	"CODE_LINE":         true,
+	"CODE_FUNC":         true,
+}
+
+// journalEncoder renders records in the journald native protocol. Each
+// annotation becomes a field with an upper case name. Values that contain
+// a newline are written in the length prefixed binary form.
+func journalEncoder(identifier string) encodeFunc {
+	return func(buf *bytes.Buffer, r *record) {
+		writeJournalField(buf, "MESSAGE", r.message)
+		writeJournalField(buf, "PRIORITY", strconv.Itoa(syslogSeverity(r.level)))
+		if identifier != "" {
+			writeJournalField(buf, "SYSLOG_IDENTIFIER", identifier)
+		}
+		if frame := r.frame(); frame.File != "" {
+			writeJournalField(buf, "CODE_FILE", frame.File)
+			writeJournalField(buf, "CODE_LINE", strconv.Itoa(frame.Line))
+			if frame.Function != "" {
+				writeJournalField(buf, "CODE_FUNC", frame.Function)
+			}
+		}
+		var fields = make(map[string]interface{}, len(r.fields))
+		flatten(fields, "", "_", r.fields)
+		var written = map[string]bool{}
+		for _, key := range sortedKeys(fields) {
+			var name = journalKey(key)
+			if journalReserved[name] || written[name] {
+				continue
+			}
+			written[name] = true
+			writeJournalField(buf, name, textValue(fields[key]))
+		}
+	}
+}
+
+func writeJournalField(buf *bytes.Buffer, key string, value string) {
+	buf.WriteString(key)
+	if !strings.Contains(value, "\n") {
+		buf.WriteByte('=')
+		buf.WriteString(value)
+		buf.WriteByte('\n')
+		return
+	}
+	buf.WriteByte('\n')
+	var size [8]byte
+	binary.LittleEndian.PutUint64(size[:], uint64(len(value)))
+	buf.Write(size[:])
+	buf.WriteString(value)
+	buf.WriteByte('\n')
+}
+
+// journalKey converts an annotation name in to a journal field name. Field
+// names are upper case letters, digits, and underscores, must not begin
+// with an underscore or a digit, and are at most 64 characters long.
+func journalKey(key string) string {
+	key = strings.Map(func(r rune) rune {
+		switch {
+		case r >= 'a' && r <= 'z':
+			return r - 'a' + 'A'
+		case (r >= 'A' && r <= 'Z') || (r >= '0' && r <= '9'):
+			return r
+		default:
+			return '_'
+		}
+	}, key)
+	key = strings.TrimLeft(key, "_0123456789")
+	if key == "" {
+		return "FIELD"
+	}
+	if len(key) > journalMaxKey {
+		key = key[:journalMaxKey]
+	}
+	return key
+}
+
+func newJournalEncoder() encodeFunc {
+	return journalEncoder(filepath.Base(os.Args[0]))
+}
+
+// JournalWriter sends each write to systemd-journald as a single datagram.
+// It is intended as the Output of a logger that uses EncoderJournald.
+// Events larger than the maximum datagram size of the socket are rejected
+// by the kernel and are not written.
+type JournalWriter struct {
+	lock sync.Mutex
+	conn net.Conn
+}
+
+// NewJournalWriter connects to the journald socket at path. JournalSocket
+// is used if the path is empty.
+func NewJournalWriter(path string) (*JournalWriter, error) {
+	if path == "" {
+		path = JournalSocket
+	}
+	var conn, err = net.Dial("unixgram", path)
+	if err != nil {
+		return nil, err
+	}
+	return &JournalWriter{conn: conn}, nil
+}
+
+// Write sends p as one journal entry.
+func (w *JournalWriter) Write(p []byte) (int, error) {
+	w.lock.Lock()
+	defer w.lock.Unlock()
+	return w.conn.Write(p)
+}
+
+// Close closes the connection to journald.
+func (w *JournalWriter) Close() error {
+	return w.conn.Close()
+}
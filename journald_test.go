@@ -0,0 +1,103 @@
+package logevent
+
+import (
+	"bytes"
+	"net"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/require"
+)
+
+func TestJournalEncoder(t *testing.T) {
+	var encode = journalEncoder("app")
+	var buf = &bytes.Buffer{}
+	encode(buf, &record{
+		level:   zerolog.InfoLevel,
+		message: "two\nlines",
+		fields: map[string]interface{}{
+			"user.id": "1234",
+			"_secret": "x",
+			"http":    map[string]interface{}{"status": 200},
+		},
+	})
+	require.Equal(t, "MESSAGE\n\x09\x00\x00\x00\x00\x00\x00\x00two\nlines\n"+
+		"PRIORITY=6\n"+
+		"SYSLOG_IDENTIFIER=app\n"+
+		"SECRET=x\n"+
+		"HTTP_STATUS=200\n"+
+		"USER_ID=1234\n", buf.String())
+
+	buf.Reset()
+	encode(buf, &record{level: zerolog.PanicLevel, message: "panic"})
+	require.Equal(t, "MESSAGE=panic\nPRIORITY=1\nSYSLOG_IDENTIFIER=app\n", buf.String())
+}
+
+func TestJournalEncoderReserved(t *testing.T) {
+	var encode = journalEncoder("app")
+	var buf = &bytes.Buffer{}
+	var pcs = make([]uintptr, 1)
+	runtime.Callers(1, pcs)
+	encode(buf, &record{
+		level:   zerolog.ErrorLevel,
+		message: "collides",
+		pc:      pcs[0],
+		fields: map[string]interface{}{
+			"priority":          "high",
+			"message":           "other",
+			"syslog_identifier": "other",
+			"code_line":         "1",
+			"user.id":           "1",
+			"user_id":           "2",
+		},
+	})
+	var lines = strings.Split(strings.TrimSuffix(buf.String(), "\n"), "\n")
+	require.Equal(t, "MESSAGE=collides", lines[0])
+	require.Equal(t, "PRIORITY=3", lines[1])
+	require.Equal(t, "SYSLOG_IDENTIFIER=app", lines[2])
+	require.True(t, strings.HasPrefix(lines[3], "CODE_FILE="), lines[3])
+	require.True(t, strings.HasPrefix(lines[4], "CODE_LINE="), lines[4])
+	require.True(t, strings.HasPrefix(lines[5], "CODE_FUNC="), lines[5])
+	require.Equal(t, []string{"USER_ID=1"}, lines[6:])
+}
+
+func TestJournalKey(t *testing.T) {
+	require.Equal(t, "TRACE_ID", journalKey("trace-id"))
+	require.Equal(t, "FIELD", journalKey("__"))
+	require.Equal(t, "A", journalKey("9a"))
+	require.Len(t, journalKey(string(make([]byte, 100))+"a"), 1)
+}
+
+func TestJournalWriter(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("unix datagram sockets are not supported")
+	}
+	// socket paths are limited in length so avoid the long test directory
+	var dir, err = os.MkdirTemp("", "journal")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+	var path = filepath.Join(dir, "socket")
+	conn, err := net.ListenUnixgram("unixgram", &net.UnixAddr{Name: path, Net: "unixgram"})
+	require.NoError(t, err)
+	defer conn.Close()
+
+	w, err := NewJournalWriter(path)
+	require.NoError(t, err)
+	defer w.Close()
+	New(Config{Output: w, Encoder: EncoderJournald, Fields: map[string]interface{}{"service": "billing"}}).Warn("to the journal")
+
+	var b = make([]byte, 4096)
+	_ = conn.SetReadDeadline(time.Now().Add(time.Second))
+	n, err := conn.Read(b)
+	require.NoError(t, err)
+	var entry = string(b[:n])
+	require.Contains(t, entry, "MESSAGE=to the journal\n")
+	require.Contains(t, entry, "PRIORITY=4\n")
+	require.Contains(t, entry, "SERVICE=billing\n")
+	require.Contains(t, entry, "CODE_FILE=")
+}
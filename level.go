@@ -0,0 +1,87 @@
+package logevent
+
+import (
+	"errors"
+	"strings"
+	"sync/atomic"
+
+	"github.com/rs/zerolog"
+)
+
+// ErrInvalidLevel is returned when a level name is not recognized.
+var ErrInvalidLevel = errors.New("invalid log level")
+
+// LevelVar is a log level that may be changed while the application is
+// running. A logger and all of its copies share the same LevelVar so a
+// change is observed by every one of them.
+type LevelVar struct {
+	level int32
+}
+
+// NewLevelVar creates a LevelVar set to the named level. Unrecognized
+// names select DEBUG, as with Config.Level.
+func NewLevelVar(level string) *LevelVar {
+	var v = &LevelVar{}
+	v.set(levelFromString(level))
+	return v
+}
+
+// Level returns the name of the current level such as INFO.
+func (v *LevelVar) Level() string {
+	return strings.ToUpper(v.get().String())
+}
+
+// SetLevel changes the current level. ErrInvalidLevel is returned, and
+// the level is left unchanged, if the name is not recognized.
+func (v *LevelVar) SetLevel(level string) error {
+	var l, ok = parseLevel(level)
+	if !ok {
+		return ErrInvalidLevel
+	}
+	v.set(l)
+	return nil
+}
+
+func (v *LevelVar) get() zerolog.Level {
+	return zerolog.Level(atomic.LoadInt32(&v.level))
+}
+
+func (v *LevelVar) set(level zerolog.Level) {
+	atomic.StoreInt32(&v.level, int32(level))
+}
+
+// GetLevelVar returns the LevelVar that controls a Logger. Nil is returned
+// for Loggers that do not support changing the level.
+func GetLevelVar(logger Logger) *LevelVar {
+	if l, ok := logger.(interface{ levelVar() *LevelVar }); ok {
+		return l.levelVar()
+	}
+	return nil
+}
+
+func (log *logger) levelVar() *LevelVar {
+	return log.level
+}
+
+// parseLevel converts a level name into a zerolog.Level. The second return
+// is false if the name is not recognized.
+func parseLevel(level string) (zerolog.Level, bool) {
+	switch strings.ToUpper(level) {
+	case "TRACE":
+		return zerolog.TraceLevel, true
+	case "DEBUG":
+		return zerolog.DebugLevel, true
+	case "INFO":
+		return zerolog.InfoLevel, true
+	case "WARN":
+		return zerolog.WarnLevel, true
+	case "ERROR":
+		return zerolog.ErrorLevel, true
+	case "FATAL":
+		return zerolog.FatalLevel, true
+	case "PANIC":
+		return zerolog.PanicLevel, true
+	default:
+		return zerolog.DebugLevel, false
+	}
+}
@@ -0,0 +1,35 @@
+package logevent
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestLevelVar(t *testing.T) {
+	var level = NewLevelVar("warn")
+	require.Equal(t, "WARN", level.Level())
+	require.NoError(t, level.SetLevel("error"))
+	require.Equal(t, "ERROR", level.Level())
+	require.Equal(t, ErrInvalidLevel, level.SetLevel("loud"))
+	require.Equal(t, "ERROR", level.Level())
+	require.Equal(t, "DEBUG", NewLevelVar("").Level())
+}
+
+func TestLevelVarShared(t *testing.T) {
+	var buff = &bytes.Buffer{}
+	var logger = New(Config{Level: "ERROR", Output: buff})
+	var copied = logger.Copy()
+	copied.Info("hidden")
+	require.Empty(t, buff.String())
+
+	require.NoError(t, GetLevelVar(logger).SetLevel("INFO"))
+	copied.Info("shown")
+	require.Contains(t, buff.String(), "shown")
+
+	var shared = NewLevelVar("ERROR")
+	var other = New(Config{LevelVar: shared, Level: "DEBUG", Output: buff})
+	require.Equal(t, shared, GetLevelVar(other))
+	require.Nil(t, GetLevelVar(NewNop()))
+}
@@ -2,54 +2,153 @@ package logevent
 
 import (
 	"io"
+	"log/slog"
 	"os"
-	"strings"
-	"sync"
+	"runtime"
+	"sync/atomic"
 	"time"
 
-	"github.com/fatih/structs"
 	"github.com/rs/zerolog"
 )
 
-type fallbackEvent struct {
-	Message string `logevent:"message"`
-}
-
 type logger struct {
-	c      Config
-	l      zerolog.Logger
-	fields *sync.Map
+	c        Config
+	name     string
+	level    *LevelVar
+	levels   *levelTable
+	backend  backend
+	renderer *renderer
+	policy   *fieldPolicy
+	sampler  *sampler
+	fields   atomic.Pointer[fieldSet]
 }
 
 // Config records the requested settings for a logger for use with New().
 type Config struct {
 	// Level at which to log. Defaults to DEBUG.
-	// Acceptable are ERROR, WARN, INFO, and DEBUG.
+	// Acceptable are PANIC, FATAL, ERROR, WARN, INFO, DEBUG, and TRACE.
 	Level string
+	// LevelVar, if set, controls the level at runtime and Level is ignored.
+	// A LevelVar may be shared by several loggers.
+	LevelVar *LevelVar
+	// Levels maps logger names to the level of loggers created with Named.
+	// The most specific dot separated prefix of a name wins and names
+	// without a match use Level. ParseLevels reads the same table from a
+	// string.
+	Levels map[string]string
 	// HumanReadable toggles the JSON format off in favor of a colorised
 	// log formatted for human readers.
 	HumanReadable bool
 	// Output defines to where logs are written. The default is os.Stdout.
 	Output io.Writer
+	// Fields are set on the logger, as with SetField, when it is created.
+	Fields map[string]interface{}
+	// DisableCaller omits the source location of the log call.
+	DisableCaller bool
+	// DisableTimestamp omits the time at which the event was logged.
+	DisableTimestamp bool
+	// ErrorStack captures a stack trace when logging at ERROR or above for
+	// errors in the event that do not carry a stack trace of their own.
+	ErrorStack bool
+	// Encoder selects the format of each event written to Output. The
+	// default is EncoderJSON. Acceptable are EncoderJSON, EncoderLogfmt,
+	// EncoderECS, EncoderGELF, EncoderRFC5424, and EncoderJournald.
+	// HumanReadable only applies to JSON.
+	Encoder string
+	// RedactFields lists patterns, in path.Match syntax, of field names set
+	// with SetField whose values are replaced with Redacted. Matching is not
+	// case sensitive. Attributes in slog groups are matched by their path,
+	// such as "request.authorization".
+	RedactFields []string
+	// HashKey is the secret used to salt the values of event fields tagged
+	// with the hash option.
+	HashKey string
+	// MaxElements limits the number of elements rendered for each slice,
+	// array, or map of structs in an event. The number of elements left out
+	// is logged with the name of the field followed by _omitted. The default
+	// is DefaultMaxElements and a negative value renders every element.
+	MaxElements int
+	// MaxDepth limits the nesting of structs rendered for an event. Deeper
+	// structs are replaced with MaxDepthReached. The default is
+	// DefaultMaxDepth.
+	MaxDepth int
+	// Async enables writing events from a background goroutine so that
+	// logging does not wait on a slow Output. Use Flush or Close to drain
+	// the queue before the application exits.
+	Async AsyncConfig
+	// Sampling limits how often noisy events are logged.
+	Sampling SamplingConfig
+	// Extractors add fields from a context to the logger returned by Ctx,
+	// in addition to those registered with RegisterExtractor.
+	Extractors []ContextExtractor
+	// ExitFunc is called with an exit code of 1 after an event is logged with
+	// Fatal. Defaults to os.Exit. Tests may replace it to observe the exit.
+	ExitFunc func(code int)
+	// Handler, if set, receives every event as an slog.Record instead of
+	// the default JSON backend. Output and HumanReadable are ignored when
+	// a Handler is given.
+	Handler slog.Handler
 }
 
-// New creates an instance of a Logger using the default backend.
+// New creates an instance of a Logger using the default backend. If the
+// Config fails Validate then an InvalidConfig event is logged at ERROR.
 func New(c Config) Logger {
+	var invalid = c.Validate()
 	if c.Output == nil {
 		c.Output = os.Stdout
 	}
-	zerolog.CallerFieldName = "file"
-	zerolog.CallerSkipFrameCount = 5
+	if c.LevelVar == nil {
+		c.LevelVar = NewLevelVar(c.Level)
+	}
+	var log = &logger{
+		c:        c,
+		level:    c.LevelVar,
+		levels:   newLevelTable(c.LevelVar, c.Levels),
+		backend:  newBackend(c),
+		renderer: &renderer{hashKey: []byte(c.HashKey), maxElements: c.MaxElements, maxDepth: c.MaxDepth},
+		policy:   newFieldPolicy(c.RedactFields),
+	}
+	for name, value := range c.Fields {
+		log.SetField(name, value)
+	}
+	log.sampler = newSampler(c.Sampling, func(level zerolog.Level, summary SamplingSummary) {
+		var message, annotations = log.renderer.event(summary, nil)
+		log.write(level, 0, message, annotations)
+	})
+	if invalid != nil {
+		var message, annotations = log.renderer.event(InvalidConfig{Reason: invalid.Error()}, nil)
+		log.write(zerolog.ErrorLevel, 0, message, annotations)
+	}
+	return log
+}
+
+func newBackend(c Config) backend {
+	var b = newOutputBackend(c)
+	if c.Async.Enabled {
+		return newAsyncBackend(b, c.Async)
+	}
+	return b
+}
+
+func newOutputBackend(c Config) backend {
+	if c.Handler != nil {
+		return &slogBackend{h: c.Handler}
+	}
+	// an unknown encoder falls back to JSON and is reported by New
+	if b, err := newEncoderBackend(c.Encoder, c.Output); err == nil && b != nil {
+		return b
+	}
 	zerolog.TimeFieldFormat = time.RFC3339Nano
-	var l = zerolog.New(c.Output).With().Caller().Timestamp().Logger().Level(levelFromString(c.Level))
+	var l = zerolog.New(c.Output)
 	if c.HumanReadable {
 		l = l.Output(zerolog.ConsoleWriter{Out: os.Stdout})
 	}
-	return &logger{
-		c:      c,
-		l:      l,
-		fields: &sync.Map{},
-	}
+	return &zerologBackend{l: l}
+}
+
+// Trace will emit the event with level TRACE.
+func (log *logger) Trace(event interface{}) {
+	log.emit(zerolog.TraceLevel, event)
 }
 
 // Debug will emit the event with level DEBUG.
@@ -72,46 +171,75 @@ func (log *logger) Error(event interface{}) {
 	log.emit(zerolog.ErrorLevel, event)
 }
 
-func (log *logger) emitString(level zerolog.Level, event string) {
-	log.emitStruct(level, fallbackEvent{Message: event})
+// Fatal will emit the event with level FATAL, flush any buffered events,
+// and then exit the application.
+func (log *logger) Fatal(event interface{}) {
+	log.emit(zerolog.FatalLevel, event)
+	log.flush()
+	if s, ok := log.c.Output.(interface{ Sync() error }); ok {
+		_ = s.Sync()
+	}
+	log.exit(1)
+}
+
+// Panic will emit the event with level PANIC and then panic with the event.
+func (log *logger) Panic(event interface{}) {
+	log.emit(zerolog.PanicLevel, event)
+	panic(event)
 }
 
-func (log *logger) emitStruct(level zerolog.Level, event interface{}) {
-	var s = structs.New(event)
-	var annotations = make(map[string]interface{})
-	buildAnnotations(s, annotations)
+func (log *logger) exit(code int) {
+	if log.c.ExitFunc != nil {
+		log.c.ExitFunc(code)
+		return
+	}
+	os.Exit(code)
+}
 
+// write applies the logger fields to a rendered event and hands it off to
+// the backend.
+func (log *logger) write(level zerolog.Level, pc uintptr, message string, annotations map[string]interface{}) {
 	// apply logger level annotations, but don't override what was logged in a struct
-	log.fields.Range(func(key interface{}, value interface{}) bool {
-		addIfNotExists(annotations, key.(string), value)
-		return true
+	log.fields.Load().apply(annotations)
+	var now time.Time
+	if !log.c.DisableTimestamp {
+		now = time.Now()
+	}
+	if log.c.DisableCaller {
+		pc = 0
+	}
+	log.backend.write(&record{
+		time:    now,
+		level:   level,
+		message: message,
+		pc:      pc,
+		fields:  annotations,
 	})
+}
 
-	var message = getMessage(s)
-	delete(annotations, "message")
-	if message == unknown {
-		// struct is lacking a Message field, or Message field is "".
-		// As a last resort, see if the event is error type
-		if _, ok := event.(error); ok {
-			message = event.(error).Error()
-		}
-	}
-	log.l.WithLevel(level).Fields(annotations).Msg(message)
+func (log *logger) enabled(level zerolog.Level) bool {
+	return level >= log.level.get()
 }
 
+// emit must only be called directly by the exported logging methods so
+// that the caller of those methods is recorded as the event source.
 func (log *logger) emit(level zerolog.Level, event interface{}) {
-	// Fallback for string values to unstructured logging. This exists to
-	// help with migration paths from unstructured to structured by allowing
-	// refactors to occur over time. It is **not** recommended to use this
-	// feature if the logs can be made into structs.
-	if event == nil {
-		event = "(nil)"
-	}
-	if msg, ok := event.(string); ok {
-		log.emitString(level, msg)
+	if !log.enabled(level) {
 		return
 	}
-	log.emitStruct(level, event)
+	if log.sampler != nil && !log.sampler.allow(level, event) {
+		return
+	}
+	var pcs [1]uintptr
+	// skip runtime.Callers, emit, and the exported logging method
+	runtime.Callers(3, pcs[:])
+	var stack []uintptr
+	if log.c.ErrorStack && level >= zerolog.ErrorLevel {
+		stack = make([]uintptr, maxStackDepth)
+		stack = stack[:runtime.Callers(3, stack)]
+	}
+	var message, annotations = log.renderer.event(event, stack)
+	log.write(level, pcs[0], message, annotations)
 }
 
 // SetField applies a contextual annotation to all future events logged with
@@ -120,34 +248,40 @@ func (log *logger) emit(level zerolog.Level, event interface{}) {
 // from a remote call) or 2) part of an emerging set of common keys that would
 // eventually be added automatically to structs via a request context.
 func (log *logger) SetField(name string, value interface{}) {
-	log.fields.Store(name, value)
+	var fields = map[string]interface{}{name: log.policy.apply(name, value)}
+	for {
+		var current = log.fields.Load()
+		if log.fields.CompareAndSwap(current, current.with(fields)) {
+			return
+		}
+	}
 }
 
 // Copy the logger of use in some other context.
 func (log *logger) Copy() Logger {
-	var copy = New(log.c).(*logger)
-	log.fields.Range(func(key interface{}, value interface{}) bool {
-		copy.fields.Store(key, value)
-		return true
-	})
+	return log.copy()
+}
+
+// copy shares the fields of the logger with the copy. Neither sees fields
+// set on the other afterwards because fields are never changed in place.
+func (log *logger) copy() *logger {
+	var copy = &logger{
+		c:        log.c,
+		name:     log.name,
+		level:    log.level,
+		levels:   log.levels,
+		backend:  log.backend,
+		renderer: log.renderer,
+		policy:   log.policy,
+		sampler:  log.sampler,
+	}
+	copy.fields.Store(log.fields.Load())
 	return copy
 }
 
-// levelFromString converts a string log level name into an xlog.Level type
-// for use with xlog.
+// levelFromString converts a string log level name into a zerolog.Level.
+// Unrecognized names select DEBUG.
 func levelFromString(level string) zerolog.Level {
-	switch strings.ToUpper(level) {
-	case "DEBUG":
-		return zerolog.DebugLevel
-	case "INFO":
-		return zerolog.InfoLevel
-	case "WARN":
-		return zerolog.WarnLevel
-	case "ERROR":
-		return zerolog.ErrorLevel
-	case "FATAL":
-		return zerolog.FatalLevel
-	default:
-		return zerolog.DebugLevel
-	}
+	var l, _ = parseLevel(level)
+	return l
 }
@@ -73,12 +73,22 @@ func TestLoggerTagsWithEventAttributesLevels(t *testing.T) {
 		{Level: zerolog.ErrorLevel, Func: func(ev eventMessage, logger Logger) {
 			logger.Error(ev)
 		}},
+		{Level: zerolog.TraceLevel, Func: func(ev eventMessage, logger Logger) {
+			logger.Trace(ev)
+		}},
+		{Level: zerolog.FatalLevel, Func: func(ev eventMessage, logger Logger) {
+			logger.Fatal(ev)
+		}},
+		{Level: zerolog.PanicLevel, Func: func(ev eventMessage, logger Logger) {
+			defer func() { _ = recover() }()
+			logger.Panic(ev)
+		}},
 	}
 	for _, currentCase := range cases {
 		t.Run(string(rune(currentCase.Level)), func(tb *testing.T) {
 			var event = eventMessage{One: "one", Two: 2, Message: "testmessage"}
 			var buff = &bytes.Buffer{}
-			var c = Config{Output: buff}
+			var c = Config{Output: buff, Level: "TRACE", ExitFunc: func(int) {}}
 			var logger = New(c)
 			logger.SetField("out-of-event", "true")
 			currentCase.Func(event, logger)
@@ -340,3 +350,37 @@ func TestLoggerFallbackToError(t *testing.T) { // nolint
 	var _, okExported = line["message"]
 	require.True(t, okExported, "log line missing nested attribute")
 }
+
+func TestLoggerFatal(t *testing.T) {
+	var buff = &bytes.Buffer{}
+	var code = -1
+	var logger = New(Config{
+		Output:   buff,
+		Level:    "ERROR",
+		Async:    AsyncConfig{Enabled: true},
+		ExitFunc: func(c int) { code = c },
+	})
+	defer Close(logger)
+	logger.Error("queued")
+	logger.Fatal("fatal")
+	require.Equal(t, 1, code)
+	// buffered events are written before exiting
+	require.Contains(t, buff.String(), "queued")
+	require.Contains(t, buff.String(), "fatal")
+}
+
+func TestLoggerPanic(t *testing.T) {
+	var buff = &bytes.Buffer{}
+	var logger = New(Config{Output: buff, Level: "PANIC"})
+	var event = eventMessage{Message: "panicked"}
+	require.PanicsWithValue(t, event, func() { logger.Panic(event) })
+	require.Contains(t, buff.String(), "panicked")
+	logger.Error("filtered")
+	require.NotContains(t, buff.String(), "filtered")
+}
+
+func TestLoggerTraceFiltered(t *testing.T) {
+	var buff = &bytes.Buffer{}
+	New(Config{Output: buff}).Trace("hidden by default")
+	require.Empty(t, buff.String())
+}
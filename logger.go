@@ -3,6 +3,8 @@ package logevent
 // Logger is a logging system abstraction that supports leveled, strictly
 // structured log emissions.
 type Logger interface {
+	// Trace will emit the event with level TRACE.
+	Trace(event interface{})
 	// Debug will emit the event with level DEBUG.
 	Debug(event interface{})
 	// Info will emit the event with level INFO.
@@ -11,9 +13,24 @@ type Logger interface {
 	Warn(event interface{})
 	// Error will emit the event with level ERROR.
 	Error(event interface{})
+	// Fatal will emit the event with level FATAL, flush any buffered
+	// events, and then exit the application.
+	Fatal(event interface{})
+	// Panic will emit the event with level PANIC and then panic with the
+	// event.
+	Panic(event interface{})
 	// SetField applies a contextual annotation to all
 	// future events logged with this logger.
 	SetField(name string, value interface{})
 	// Copy the logger of use in some other context.
 	Copy() Logger
+	// With returns a copy of the logger with the fields, given as
+	// alternating keys and values, added. The logger is not changed.
+	With(keysAndValues ...interface{}) Logger
+	// WithFields returns a copy of the logger with the fields added. The
+	// logger is not changed.
+	WithFields(fields map[string]interface{}) Logger
+	// Named creates a copy of the logger for the named component. Names
+	// are joined with a dot when a named logger is named again.
+	Named(name string) Logger
 }
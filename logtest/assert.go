@@ -0,0 +1,132 @@
+package logtest
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+
+	"github.com/asecurityteam/logevent/v2"
+)
+
+// UpdateGoldenEnv is the environment variable that, when set to any
+// non-empty value, causes AssertGolden to rewrite golden files with the
+// current output instead of comparing against them.
+const UpdateGoldenEnv = "LOGTEST_UPDATE_GOLDEN"
+
+// TB is the subset of testing.TB used by the assertion helpers.
+type TB interface {
+	Helper()
+	Errorf(format string, args ...interface{})
+}
+
+// AssertLogged fails the test unless an event was recorded at the level
+// that renders the same message and annotations as the given event. The
+// comparison is made on the rendered form so that tag defaults and
+// redaction apply to both sides.
+func (r *Recorder) AssertLogged(t TB, level string, event interface{}) bool {
+	t.Helper()
+	if len(r.matching(level, event)) > 0 {
+		return true
+	}
+	var message, annotations = logevent.Render(event)
+	t.Errorf("expected a %s event %T with message %q and annotations %v but recorded:\n%s",
+		level, event, message, annotations, r.describe())
+	return false
+}
+
+// AssertNotLogged fails the test if an event was recorded at the level
+// that renders the same as the given event.
+func (r *Recorder) AssertNotLogged(t TB, level string, event interface{}) bool {
+	t.Helper()
+	if len(r.matching(level, event)) == 0 {
+		return true
+	}
+	t.Errorf("expected no %s event %T matching %+v but one was recorded", level, event, event)
+	return false
+}
+
+func (r *Recorder) matching(level string, event interface{}) []Entry {
+	var t = eventType(event)
+	var message, annotations = logevent.Render(event)
+	return r.Filter(func(e Entry) bool {
+		return e.Level == level &&
+			eventType(e.Event) == t &&
+			e.Message == message &&
+			reflect.DeepEqual(e.Annotations, annotations)
+	})
+}
+
+func (r *Recorder) describe() string {
+	var buf = &bytes.Buffer{}
+	for _, e := range r.Entries() {
+		fmt.Fprintf(buf, "\t%s %T %q %v\n", e.Level, e.Event, e.Message, e.Annotations)
+	}
+	if buf.Len() == 0 {
+		return "\t(nothing)\n"
+	}
+	return buf.String()
+}
+
+// goldenEntry is the serialised form of an Entry in a golden file.
+type goldenEntry struct {
+	Level       string                 `json:"level"`
+	Message     string                 `json:"message"`
+	Annotations map[string]interface{} `json:"annotations,omitempty"`
+	Fields      map[string]interface{} `json:"fields,omitempty"`
+}
+
+// Golden renders every recorded event as indented JSON for comparison
+// with a golden file.
+func (r *Recorder) Golden() ([]byte, error) {
+	var entries = r.Entries()
+	var golden = make([]goldenEntry, 0, len(entries))
+	for _, e := range entries {
+		golden = append(golden, goldenEntry{
+			Level:       e.Level,
+			Message:     e.Message,
+			Annotations: e.Annotations,
+			Fields:      e.Fields,
+		})
+	}
+	var b, err = json.MarshalIndent(golden, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	return append(b, '\n'), nil
+}
+
+// AssertGolden fails the test unless the recorded events match the
+// contents of the golden file at path. Setting the UpdateGoldenEnv
+// environment variable writes the recorded events to the file instead.
+func (r *Recorder) AssertGolden(t TB, path string) bool {
+	t.Helper()
+	var actual, err = r.Golden()
+	if err != nil {
+		t.Errorf("failed to render recorded events: %s", err)
+		return false
+	}
+	if os.Getenv(UpdateGoldenEnv) != "" {
+		if err = os.MkdirAll(filepath.Dir(path), 0755); err == nil {
+			err = os.WriteFile(path, actual, 0644)
+		}
+		if err != nil {
+			t.Errorf("failed to update golden file %s: %s", path, err)
+			return false
+		}
+		return true
+	}
+	var expected []byte
+	expected, err = os.ReadFile(path)
+	if err != nil {
+		t.Errorf("failed to read golden file %s: %s", path, err)
+		return false
+	}
+	if !bytes.Equal(expected, actual) {
+		t.Errorf("recorded events do not match golden file %s\nexpected:\n%s\nactual:\n%s", path, expected, actual)
+		return false
+	}
+	return true
+}
@@ -0,0 +1,61 @@
+package logtest
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+type fakeTB struct {
+	errors []string
+}
+
+func (t *fakeTB) Helper() {}
+
+func (t *fakeTB) Errorf(format string, args ...interface{}) {
+	t.errors = append(t.errors, fmt.Sprintf(format, args...))
+}
+
+func TestAssertLogged(t *testing.T) {
+	var r = New()
+	r.Info(cacheMiss{Key: "a"})
+
+	require.True(t, r.AssertLogged(t, LevelInfo, cacheMiss{Key: "a"}))
+	require.True(t, r.AssertNotLogged(t, LevelWarn, cacheMiss{Key: "a"}))
+
+	var fake = &fakeTB{}
+	require.False(t, r.AssertLogged(fake, LevelInfo, cacheMiss{Key: "b"}))
+	require.False(t, r.AssertLogged(fake, LevelInfo, userLogin{}))
+	require.False(t, r.AssertNotLogged(fake, LevelInfo, &cacheMiss{Key: "a"}))
+	require.Len(t, fake.errors, 3)
+	require.Contains(t, fake.errors[0], "cache-miss")
+}
+
+func TestAssertGolden(t *testing.T) {
+	var r = New()
+	r.SetField("service", "test")
+	r.Info(cacheMiss{Key: "a"})
+	r.Warn(userLogin{User: "alice", Token: "secret"})
+	require.True(t, r.AssertGolden(t, filepath.Join("testdata", "golden.json")))
+
+	var fake = &fakeTB{}
+	r.Error("extra")
+	require.False(t, r.AssertGolden(fake, filepath.Join("testdata", "golden.json")))
+	require.False(t, r.AssertGolden(fake, filepath.Join("testdata", "missing.json")))
+	require.Len(t, fake.errors, 2)
+}
+
+func TestAssertGoldenUpdate(t *testing.T) {
+	var path = filepath.Join(t.TempDir(), "nested", "golden.json")
+	var r = New()
+	r.Info(cacheMiss{Key: "a"})
+	t.Setenv(UpdateGoldenEnv, "1")
+	require.True(t, r.AssertGolden(t, path))
+	var b, err = os.ReadFile(path)
+	require.Nil(t, err)
+	var expected, _ = r.Golden()
+	require.Equal(t, expected, b)
+}
@@ -0,0 +1,226 @@
+package logtest
+
+import (
+	"fmt"
+	"reflect"
+	"sync"
+
+	"github.com/asecurityteam/logevent/v2"
+)
+
+// Level names recorded for each Entry. They match the level names written
+// by the default logevent backend.
+const (
+	LevelTrace = "trace"
+	LevelDebug = "debug"
+	LevelInfo  = "info"
+	LevelWarn  = "warn"
+	LevelError = "error"
+	LevelFatal = "fatal"
+	LevelPanic = "panic"
+)
+
+// Entry is a single event captured by a Recorder.
+type Entry struct {
+	// Level is the name of the level the event was logged at.
+	Level string
+	// Message is the rendered message of the event.
+	Message string
+	// Annotations are the rendered logevent fields of the event.
+	Annotations map[string]interface{}
+	// Fields are the values set with SetField at the time of the event.
+	Fields map[string]interface{}
+	// Event is the original value passed to the logging method.
+	Event interface{}
+}
+
+// entries is the storage shared by a Recorder and all of its copies.
+type entries struct {
+	lock    sync.Mutex
+	entries []Entry
+}
+
+// Recorder is a logevent.Logger that keeps every event in memory so that
+// tests can make assertions about what was logged. Copies of a Recorder
+// record in to the same storage but, as with logevent.Logger, have their
+// own fields.
+type Recorder struct {
+	store  *entries
+	lock   sync.Mutex
+	fields map[string]interface{}
+}
+
+var _ logevent.Logger = &Recorder{}
+
+// New creates an empty Recorder.
+func New() *Recorder {
+	return &Recorder{
+		store:  &entries{},
+		fields: make(map[string]interface{}),
+	}
+}
+
+// Trace records the event with level TRACE.
+func (r *Recorder) Trace(event interface{}) {
+	r.record(LevelTrace, event)
+}
+
+// Debug records the event with level DEBUG.
+func (r *Recorder) Debug(event interface{}) {
+	r.record(LevelDebug, event)
+}
+
+// Info records the event with level INFO.
+func (r *Recorder) Info(event interface{}) {
+	r.record(LevelInfo, event)
+}
+
+// Warn records the event with level WARN.
+func (r *Recorder) Warn(event interface{}) {
+	r.record(LevelWarn, event)
+}
+
+// Error records the event with level ERROR.
+func (r *Recorder) Error(event interface{}) {
+	r.record(LevelError, event)
+}
+
+// Fatal records the event with level FATAL. Unlike other Loggers it does
+// not exit so that tests may assert on the event.
+func (r *Recorder) Fatal(event interface{}) {
+	r.record(LevelFatal, event)
+}
+
+// Panic records the event with level PANIC and then panics with the event.
+func (r *Recorder) Panic(event interface{}) {
+	r.record(LevelPanic, event)
+	panic(event)
+}
+
+// SetField applies a contextual annotation to all future events recorded
+// with this Recorder.
+func (r *Recorder) SetField(name string, value interface{}) {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	r.fields[name] = value
+}
+
+// Copy the Recorder for use in some other context. The copy records in to
+// the same storage as the original.
+func (r *Recorder) Copy() logevent.Logger {
+	return &Recorder{
+		store:  r.store,
+		fields: r.copyFields(),
+	}
+}
+
+// With copies the Recorder and adds the fields, given as alternating keys
+// and values, to the copy. A final key without a value is recorded as a
+// value of "!BADKEY", as with log/slog.
+func (r *Recorder) With(keysAndValues ...interface{}) logevent.Logger {
+	var copy = r.Copy().(*Recorder)
+	for x := 0; x < len(keysAndValues); x = x + 2 {
+		if x+1 == len(keysAndValues) {
+			copy.fields["!BADKEY"] = keysAndValues[x]
+			break
+		}
+		copy.fields[fmt.Sprint(keysAndValues[x])] = keysAndValues[x+1]
+	}
+	return copy
+}
+
+// WithFields copies the Recorder and adds the fields to the copy.
+func (r *Recorder) WithFields(fields map[string]interface{}) logevent.Logger {
+	var copy = r.Copy().(*Recorder)
+	for name, value := range fields {
+		copy.fields[name] = value
+	}
+	return copy
+}
+
+// Named copies the Recorder and sets the logevent.LoggerKey field to the
+// name, joined with a dot to the name of the Recorder if it has one.
+func (r *Recorder) Named(name string) logevent.Logger {
+	var copy = r.Copy().(*Recorder)
+	if parent, ok := copy.fields[logevent.LoggerKey].(string); ok && parent != "" {
+		name = parent + "." + name
+	}
+	copy.fields[logevent.LoggerKey] = name
+	return copy
+}
+
+func (r *Recorder) copyFields() map[string]interface{} {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	var fields = make(map[string]interface{}, len(r.fields))
+	for key, value := range r.fields {
+		fields[key] = value
+	}
+	return fields
+}
+
+func (r *Recorder) record(level string, event interface{}) {
+	var message, annotations = logevent.Render(event)
+	var entry = Entry{
+		Level:       level,
+		Message:     message,
+		Annotations: annotations,
+		Fields:      r.copyFields(),
+		Event:       event,
+	}
+	r.store.lock.Lock()
+	defer r.store.lock.Unlock()
+	r.store.entries = append(r.store.entries, entry)
+}
+
+// Entries returns every recorded event in the order they were logged.
+func (r *Recorder) Entries() []Entry {
+	r.store.lock.Lock()
+	defer r.store.lock.Unlock()
+	var result = make([]Entry, len(r.store.entries))
+	copy(result, r.store.entries)
+	return result
+}
+
+// Reset discards every recorded event.
+func (r *Recorder) Reset() {
+	r.store.lock.Lock()
+	defer r.store.lock.Unlock()
+	r.store.entries = nil
+}
+
+// Filter returns the recorded events for which the match function returns
+// true.
+func (r *Recorder) Filter(match func(Entry) bool) []Entry {
+	var result []Entry
+	for _, entry := range r.Entries() {
+		if match(entry) {
+			result = append(result, entry)
+		}
+	}
+	return result
+}
+
+// OfType returns the recorded events that have the same Go type as the
+// given event. Pointers to events match the type they point to.
+func (r *Recorder) OfType(event interface{}) []Entry {
+	var t = eventType(event)
+	return r.Filter(func(e Entry) bool {
+		return eventType(e.Event) == t
+	})
+}
+
+// AtLevel returns the recorded events logged at the named level.
+func (r *Recorder) AtLevel(level string) []Entry {
+	return r.Filter(func(e Entry) bool {
+		return e.Level == level
+	})
+}
+
+func eventType(event interface{}) reflect.Type {
+	var t = reflect.TypeOf(event)
+	if t != nil && t.Kind() == reflect.Ptr {
+		return t.Elem()
+	}
+	return t
+}
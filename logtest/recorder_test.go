@@ -0,0 +1,83 @@
+package logtest
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/asecurityteam/logevent/v2"
+)
+
+type cacheMiss struct {
+	Key     string `logevent:"key"`
+	Message string `logevent:"message,default=cache-miss"`
+}
+
+type userLogin struct {
+	User    string `logevent:"user"`
+	Token   string `logevent:"token,redact"`
+	Message string `logevent:"message,default=user-login"`
+}
+
+func TestRecorder(t *testing.T) {
+	var r = New()
+	var logger logevent.Logger = r
+	logger.SetField("service", "test")
+	logger.Info(cacheMiss{Key: "a"})
+	var copied = logger.Copy()
+	copied.SetField("copied", true)
+	copied.Warn(&userLogin{User: "alice", Token: "secret"})
+	logger.Error(errors.New("boom"))
+	logger.Debug("plain")
+
+	var entries = r.Entries()
+	require.Len(t, entries, 4)
+	require.Equal(t, LevelInfo, entries[0].Level)
+	require.Equal(t, "cache-miss", entries[0].Message)
+	require.Equal(t, map[string]interface{}{"key": "a"}, entries[0].Annotations)
+	require.Equal(t, map[string]interface{}{"service": "test"}, entries[0].Fields)
+	require.Equal(t, cacheMiss{Key: "a"}, entries[0].Event)
+
+	require.Equal(t, logevent.Redacted, entries[1].Annotations["token"])
+	require.Equal(t, true, entries[1].Fields["copied"])
+	require.NotContains(t, entries[2].Fields, "copied")
+	require.Equal(t, "boom", entries[2].Message)
+	require.Equal(t, "plain", entries[3].Message)
+
+	require.Len(t, r.OfType(userLogin{}), 1)
+	require.Len(t, r.OfType(&cacheMiss{}), 1)
+	require.Len(t, r.AtLevel(LevelError), 1)
+
+	r.Reset()
+	require.Empty(t, r.Entries())
+	require.Empty(t, copied.(*Recorder).Entries())
+}
+
+func TestRecorderNamed(t *testing.T) {
+	var r = New()
+	r.Named("billing").Named("invoice").Info("named")
+	r.Info("root")
+	var entries = r.Entries()
+	require.Equal(t, "billing.invoice", entries[0].Fields[logevent.LoggerKey])
+	require.NotContains(t, entries[1].Fields, logevent.LoggerKey)
+}
+
+func TestRecorderFatalAndPanic(t *testing.T) {
+	var r = New()
+	r.Trace("trace")
+	r.Fatal("fatal")
+	require.Panics(t, func() { r.Panic("panic") })
+	require.Len(t, r.AtLevel(LevelTrace), 1)
+	require.Len(t, r.AtLevel(LevelFatal), 1)
+	require.Len(t, r.AtLevel(LevelPanic), 1)
+}
+
+func TestRecorderWith(t *testing.T) {
+	var r = New()
+	r.With("user", "alice", "dangling").WithFields(map[string]interface{}{"service": "billing"}).Info("with")
+	r.Info("root")
+	var entries = r.Entries()
+	require.Equal(t, map[string]interface{}{"user": "alice", "!BADKEY": "dangling", "service": "billing"}, entries[0].Fields)
+	require.Empty(t, entries[1].Fields)
+}
@@ -0,0 +1,118 @@
+package logevent
+
+import (
+	"encoding"
+	"fmt"
+	"log/slog"
+	"reflect"
+	"time"
+)
+
+// LogMarshaler is implemented by types that control how they are logged,
+// wherever they appear in an event. The returned value is rendered in
+// place of the original.
+type LogMarshaler interface {
+	MarshalLogEvent() interface{}
+}
+
+var (
+	timeType          = reflect.TypeOf(time.Time{})
+	durationType      = reflect.TypeOf(time.Duration(0))
+	logMarshalerType  = reflect.TypeOf((*LogMarshaler)(nil)).Elem()
+	logValuerType     = reflect.TypeOf((*slog.LogValuer)(nil)).Elem()
+	textMarshalerType = reflect.TypeOf((*encoding.TextMarshaler)(nil)).Elem()
+	stringerType      = reflect.TypeOf((*fmt.Stringer)(nil)).Elem()
+)
+
+// marshals reports whether values of a type, or pointers to them, choose
+// their own representation with any of the interfaces that are honoured
+// when rendering.
+func marshals(t reflect.Type) bool {
+	var base = t
+	if base.Kind() == reflect.Ptr {
+		base = base.Elem()
+	}
+	if base == timeType || base == durationType {
+		return false
+	}
+	for _, candidate := range []reflect.Type{t, reflect.PointerTo(base)} {
+		for _, i := range []reflect.Type{logMarshalerType, logValuerType, textMarshalerType, stringerType} {
+			if candidate.Implements(i) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// methodValue returns the value held by v for checking the methods that it
+// implements. Values are returned as pointers so that methods with pointer
+// receivers are found, copying values that are not addressable, so that
+// an event logs the same whether it is passed by value or by pointer. The
+// second return is false for nil values.
+func methodValue(v reflect.Value) (interface{}, bool) {
+	if !v.IsValid() {
+		return nil, false
+	}
+	if v.Kind() == reflect.Interface {
+		v = v.Elem()
+	}
+	if !v.IsValid() || (v.Kind() == reflect.Ptr && v.IsNil()) {
+		return nil, false
+	}
+	if v.Kind() == reflect.Ptr {
+		return v.Interface(), true
+	}
+	if v.CanAddr() {
+		return v.Addr().Interface(), true
+	}
+	if reflect.PointerTo(v.Type()).NumMethod() > v.Type().NumMethod() {
+		var copied = reflect.New(v.Type())
+		copied.Elem().Set(v)
+		return copied.Interface(), true
+	}
+	return v.Interface(), true
+}
+
+// marshalValue returns the representation that a value chooses for itself
+// with MarshalLogEvent or, failing that, slog.LogValuer.
+func marshalValue(v reflect.Value) (interface{}, bool) {
+	var value, ok = methodValue(v)
+	if !ok {
+		return nil, false
+	}
+	switch m := value.(type) {
+	case LogMarshaler:
+		return m.MarshalLogEvent(), true
+	case slog.LogValuer:
+		return slogValue(m.LogValue()), true
+	default:
+		return nil, false
+	}
+}
+
+// textualValue returns the text of a value with encoding.TextMarshaler or,
+// failing that, fmt.Stringer. Times and durations are left to the encoders,
+// which render them natively.
+func textualValue(v reflect.Value) (string, bool) {
+	var value, ok = methodValue(v)
+	if !ok {
+		return "", false
+	}
+	var t = reflect.TypeOf(value)
+	if t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	if t == timeType || t == durationType {
+		return "", false
+	}
+	if m, ok := value.(encoding.TextMarshaler); ok {
+		if text, err := m.MarshalText(); err == nil {
+			return string(text), true
+		}
+	}
+	if m, ok := value.(fmt.Stringer); ok {
+		return m.String(), true
+	}
+	return "", false
+}
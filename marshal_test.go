@@ -0,0 +1,108 @@
+package logevent
+
+import (
+	"log/slog"
+	"net"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+type money struct {
+	cents    int64
+	currency string
+}
+
+func (m money) MarshalLogEvent() interface{} {
+	return map[string]interface{}{"amount": float64(m.cents) / 100, "currency": m.currency}
+}
+
+type arn struct {
+	Partition string
+	Resource  string
+}
+
+func (a *arn) LogValue() slog.Value {
+	return slog.GroupValue(slog.String("partition", a.Partition), slog.String("resource", a.Resource))
+}
+
+type prefix struct {
+	Bits int `logevent:"bits"`
+}
+
+func (p prefix) String() string {
+	return "10.0.0.0/" + strconv.Itoa(p.Bits)
+}
+
+type account struct {
+	ID      string `logevent:"id"`
+	Message string `logevent:"message,default=account"`
+}
+
+func (a account) MarshalLogEvent() interface{} {
+	return account{ID: "account-" + a.ID}
+}
+
+type eventWithCustomTypes struct {
+	Price    money         `logevent:"price"`
+	Resource *arn          `logevent:"resource"`
+	Network  prefix        `logevent:"network"`
+	Address  net.IP        `logevent:"address"`
+	Nil      *arn          `logevent:"nil"`
+	Elapsed  time.Duration `logevent:"elapsed"`
+	Account  account       `logevent:"account,flatten"`
+	Prices   []money       `logevent:"prices"`
+	Networks []prefix      `logevent:"networks"`
+	Message  string        `logevent:"message,default=custom"`
+}
+
+func TestRenderCustomTypes(t *testing.T) {
+	var _, annotations = Render(eventWithCustomTypes{
+		Price:    money{cents: 1250, currency: "USD"},
+		Resource: &arn{Partition: "aws", Resource: "bucket"},
+		Network:  prefix{Bits: 8},
+		Address:  net.ParseIP("10.1.2.3"),
+		Elapsed:  time.Second,
+		Account:  account{ID: "1"},
+		Prices:   []money{{cents: 1, currency: "EUR"}},
+		Networks: []prefix{{Bits: 16}},
+	})
+	require.Equal(t, map[string]interface{}{"amount": 12.5, "currency": "USD"}, annotations["price"])
+	require.Equal(t, map[string]interface{}{"partition": "aws", "resource": "bucket"}, annotations["resource"])
+	require.Equal(t, "10.0.0.0/8", annotations["network"])
+	require.Equal(t, "10.1.2.3", annotations["address"])
+	require.Nil(t, annotations["nil"])
+	require.Equal(t, time.Second, annotations["elapsed"])
+	require.Equal(t, "account-1", annotations["account.id"])
+	require.Equal(t, []interface{}{map[string]interface{}{"amount": 0.01, "currency": "EUR"}}, annotations["prices"])
+	require.Equal(t, []interface{}{"10.0.0.0/16"}, annotations["networks"])
+}
+
+func TestRenderMarshaledEvent(t *testing.T) {
+	var message, annotations = Render(account{ID: "2"})
+	require.Equal(t, "account", message)
+	require.Equal(t, "account-2", annotations["id"])
+
+	message, _ = Render(&arn{Partition: "aws"})
+	require.Equal(t, unknown, message)
+}
+
+type eventWithPointerReceiver struct {
+	Resource arn    `logevent:"resource"`
+	Message  string `logevent:"message,default=pointer-receiver"`
+}
+
+func TestRenderPointerReceiverByValue(t *testing.T) {
+	var event = eventWithPointerReceiver{Resource: arn{Partition: "aws", Resource: "bucket"}}
+	var byValueMessage, byValue = Render(event)
+	var byPointerMessage, byPointer = Render(&event)
+	require.Equal(t, byPointerMessage, byValueMessage)
+	require.Equal(t, byPointer, byValue)
+	require.Equal(t, map[string]interface{}{"partition": "aws", "resource": "bucket"}, byValue["resource"])
+
+	_, byValue = Render(arn{Partition: "aws"})
+	_, byPointer = Render(&arn{Partition: "aws"})
+	require.Equal(t, byPointer, byValue)
+}
@@ -0,0 +1,96 @@
+package logevent
+
+import (
+	"fmt"
+	"os"
+	"strings"
+)
+
+const (
+	// LoggerKey is the key name of the logger name set by Named.
+	LoggerKey = "logger"
+	// LevelsEnv is the environment variable read by LevelsFromEnv.
+	LevelsEnv = "LOGEVENT_LEVELS"
+)
+
+// levelTable resolves the level of a named logger from the most specific
+// configured prefix of its name. It is shared by a logger and its copies.
+type levelTable struct {
+	root   *LevelVar
+	levels map[string]*LevelVar
+}
+
+func newLevelTable(root *LevelVar, levels map[string]string) *levelTable {
+	var t = &levelTable{root: root, levels: make(map[string]*LevelVar, len(levels))}
+	for name, level := range levels {
+		t.levels[strings.Trim(name, ".")] = NewLevelVar(level)
+	}
+	return t
+}
+
+// resolve finds the level of a name. The name itself is checked first
+// followed by each of its dot separated prefixes.
+func (t *levelTable) resolve(name string) *LevelVar {
+	for name != "" {
+		if level, ok := t.levels[name]; ok {
+			return level
+		}
+		var dot = strings.LastIndexByte(name, '.')
+		if dot < 0 {
+			break
+		}
+		name = name[:dot]
+	}
+	return t.root
+}
+
+// Named creates a copy of the logger for a component. The name is added
+// to the name of the logger, separated by a dot, and is set as the
+// LoggerKey field. The level of the copy is the most specific match for
+// its name in Config.Levels.
+func (log *logger) Named(name string) Logger {
+	var copy = log.copy()
+	copy.name = joinName(log.name, name)
+	copy.level = log.levels.resolve(copy.name)
+	copy.SetField(LoggerKey, copy.name)
+	return copy
+}
+
+func joinName(parent string, name string) string {
+	name = strings.Trim(name, ".")
+	if parent == "" {
+		return name
+	}
+	if name == "" {
+		return parent
+	}
+	return parent + "." + name
+}
+
+// ParseLevels parses a comma separated list of name=LEVEL pairs, such as
+// "billing=DEBUG,billing.invoice=WARN", for use as Config.Levels.
+func ParseLevels(spec string) (map[string]string, error) {
+	var levels = make(map[string]string)
+	for _, pair := range strings.Split(spec, ",") {
+		pair = strings.TrimSpace(pair)
+		if pair == "" {
+			continue
+		}
+		var name, level, ok = strings.Cut(pair, "=")
+		name, level = strings.TrimSpace(name), strings.TrimSpace(level)
+		if !ok || name == "" {
+			return nil, fmt.Errorf("invalid logger level %q", pair)
+		}
+		if _, valid := parseLevel(level); !valid {
+			return nil, fmt.Errorf("%w %q for logger %q", ErrInvalidLevel, level, name)
+		}
+		levels[name] = level
+	}
+	return levels, nil
+}
+
+// LevelsFromEnv parses the LevelsEnv environment variable with
+// ParseLevels. An empty map is returned if the variable is not set.
+func LevelsFromEnv() (map[string]string, error) {
+	return ParseLevels(os.Getenv(LevelsEnv))
+}
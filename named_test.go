@@ -0,0 +1,61 @@
+package logevent
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestNamedLevels(t *testing.T) {
+	var buff = &bytes.Buffer{}
+	var logger = New(Config{Level: "WARN", Output: buff, Levels: map[string]string{
+		"billing":         "DEBUG",
+		"billing.invoice": "ERROR",
+	}})
+
+	var billing = logger.Named("billing")
+	billing.Debug("billing debug")
+	billing.Named("payment").Debug("payment debug")
+	logger.Named("billing.invoice").Warn("invoice warn")
+	billing.Named("invoice").Error("invoice error")
+	logger.Named("shipping").Info("shipping info")
+	logger.Info("root info")
+
+	var lines = strings.Split(strings.TrimSpace(buff.String()), "\n")
+	require.Len(t, lines, 3)
+	var names []string
+	for _, line := range lines {
+		var fields map[string]interface{}
+		require.NoError(t, json.Unmarshal([]byte(line), &fields))
+		names = append(names, fields[LoggerKey].(string))
+	}
+	require.Equal(t, []string{"billing", "billing.payment", "billing.invoice"}, names)
+	require.Contains(t, buff.String(), "invoice error")
+}
+
+func TestNamedLevelVar(t *testing.T) {
+	var logger = New(Config{Level: "INFO", Levels: map[string]string{"billing": "ERROR"}})
+	require.Equal(t, GetLevelVar(logger), GetLevelVar(logger.Named("shipping")))
+	var billing = GetLevelVar(logger.Named("billing"))
+	require.Equal(t, "ERROR", billing.Level())
+	require.Equal(t, billing, GetLevelVar(logger.Named("billing").Named("invoice").Copy()))
+}
+
+func TestParseLevels(t *testing.T) {
+	var levels, err = ParseLevels(" billing = debug, billing.invoice=WARN,")
+	require.NoError(t, err)
+	require.Equal(t, map[string]string{"billing": "debug", "billing.invoice": "WARN"}, levels)
+
+	_, err = ParseLevels("billing")
+	require.Error(t, err)
+	_, err = ParseLevels("billing=WARNING")
+	require.ErrorIs(t, err, ErrInvalidLevel)
+
+	t.Setenv(LevelsEnv, "billing=ERROR")
+	levels, err = LevelsFromEnv()
+	require.NoError(t, err)
+	require.Equal(t, map[string]string{"billing": "ERROR"}, levels)
+}
@@ -0,0 +1,57 @@
+package logevent
+
+type nopLogger struct{}
+
+// NewNop creates a Logger that discards every event. It is useful as the
+// Default Logger in tests and background jobs where logging is unwanted.
+func NewNop() Logger {
+	return nopLogger{}
+}
+
+// Trace discards the event.
+func (nopLogger) Trace(interface{}) {}
+
+// Debug discards the event.
+func (nopLogger) Debug(interface{}) {}
+
+// Info discards the event.
+func (nopLogger) Info(interface{}) {}
+
+// Warn discards the event.
+func (nopLogger) Warn(interface{}) {}
+
+// Error discards the event.
+func (nopLogger) Error(interface{}) {}
+
+// Fatal discards the event. Like logtest.Recorder it does not exit, so
+// that code under test is not ended by a Logger that is meant to do
+// nothing.
+func (nopLogger) Fatal(interface{}) {}
+
+// Panic discards the event and panics with it.
+func (nopLogger) Panic(event interface{}) {
+	panic(event)
+}
+
+// SetField discards the field.
+func (nopLogger) SetField(string, interface{}) {}
+
+// Copy returns the same no-op Logger.
+func (l nopLogger) Copy() Logger {
+	return l
+}
+
+// Named returns the same no-op Logger.
+func (l nopLogger) Named(string) Logger {
+	return l
+}
+
+// With returns the same no-op Logger.
+func (l nopLogger) With(...interface{}) Logger {
+	return l
+}
+
+// WithFields returns the same no-op Logger.
+func (l nopLogger) WithFields(map[string]interface{}) Logger {
+	return l
+}
@@ -0,0 +1,194 @@
+package logevent
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"path"
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+const (
+	// Redacted replaces the value of any field that is redacted.
+	Redacted = "[REDACTED]"
+
+	redactOption = "redact"
+	hashOption   = "hash"
+	maskOption   = "mask="
+	maskFirst    = "first"
+	maskLast     = "last"
+	maskRune     = '*'
+)
+
+type redactMode int
+
+const (
+	redactNone redactMode = iota
+	redactFull
+	redactHash
+	redactMask
+)
+
+// redaction is the compiled form of the redact, hash, and mask tag
+// options.
+type redaction struct {
+	mode redactMode
+	// keep is the number of characters left visible by a mask.
+	keep int
+	// last is true if a mask keeps the trailing characters visible.
+	last bool
+}
+
+// errMaskSpec is returned by parseRedaction for a mask that is not of the
+// form firstN or lastN.
+var errMaskSpec = errors.New("mask must be firstN or lastN")
+
+// parseRedaction reads a redaction option from a single tag option. The
+// second return is false if the option is not a redaction option. A mask
+// that is not recognized, such as mask=lst4, returns an error along with a
+// redaction that masks every character so that a typo never reveals the
+// value.
+func parseRedaction(option string) (redaction, bool, error) {
+	switch {
+	case option == redactOption:
+		return redaction{mode: redactFull}, true, nil
+	case option == hashOption:
+		return redaction{mode: redactHash}, true, nil
+	case strings.HasPrefix(option, maskOption):
+		var spec = strings.TrimPrefix(option, maskOption)
+		var r = redaction{mode: redactMask}
+		var keep string
+		switch {
+		case strings.HasPrefix(spec, maskLast):
+			r.last = true
+			keep = strings.TrimPrefix(spec, maskLast)
+		case strings.HasPrefix(spec, maskFirst):
+			keep = strings.TrimPrefix(spec, maskFirst)
+		default:
+			return redaction{mode: redactMask}, true, errMaskSpec
+		}
+		var n, err = strconv.Atoi(keep)
+		if err != nil || n < 0 {
+			return redaction{mode: redactMask}, true, errMaskSpec
+		}
+		r.keep = n
+		return r, true, nil
+	default:
+		return redaction{}, false, nil
+	}
+}
+
+// apply redacts the value according to the compiled options. Pointers and
+// interfaces are followed so that the value they refer to is redacted
+// rather than its address. Nil and zero values are left alone so that
+// absent data is not made to look present.
+func (r redaction) apply(key []byte, value interface{}) interface{} {
+	if r.mode == redactNone || value == nil {
+		return value
+	}
+	var v = reflect.ValueOf(value)
+	for v.Kind() == reflect.Ptr || v.Kind() == reflect.Interface {
+		if v.IsNil() {
+			return nil
+		}
+		v = v.Elem()
+	}
+	if v.IsZero() {
+		return v.Interface()
+	}
+	value = v.Interface()
+	switch r.mode {
+	case redactHash:
+		return hashValue(key, value)
+	case redactMask:
+		return maskValue(r.keep, r.last, value)
+	default:
+		return Redacted
+	}
+}
+
+func valueString(value interface{}) string {
+	if s, ok := value.(string); ok {
+		return s
+	}
+	return fmt.Sprint(value)
+}
+
+// hashValue renders the value as a hex encoded HMAC-SHA256 using the key
+// as the salt so that equal values can be correlated across events without
+// revealing them.
+func hashValue(key []byte, value interface{}) string {
+	var mac = hmac.New(sha256.New, key)
+	_, _ = mac.Write([]byte(valueString(value)))
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// maskValue replaces all but keep characters of the value with a mask.
+func maskValue(keep int, last bool, value interface{}) string {
+	var runes = []rune(valueString(value))
+	if keep > len(runes) || keep < 0 {
+		keep = 0
+	}
+	var start, end = keep, len(runes)
+	if last {
+		start, end = 0, len(runes)-keep
+	}
+	for x := start; x < end; x = x + 1 {
+		runes[x] = maskRune
+	}
+	return string(runes)
+}
+
+// fieldPolicy redacts values of fields set on a logger by name.
+type fieldPolicy struct {
+	patterns []string
+}
+
+func newFieldPolicy(patterns []string) *fieldPolicy {
+	var p = &fieldPolicy{patterns: make([]string, 0, len(patterns))}
+	for _, pattern := range patterns {
+		p.patterns = append(p.patterns, strings.ToLower(pattern))
+	}
+	return p
+}
+
+// matches reports whether the named field is redacted.
+func (p *fieldPolicy) matches(name string) bool {
+	var lower = strings.ToLower(name)
+	for _, pattern := range p.patterns {
+		if ok, _ := path.Match(pattern, lower); ok {
+			return true
+		}
+	}
+	return false
+}
+
+// apply returns the value to log for the named field.
+func (p *fieldPolicy) apply(name string, value interface{}) interface{} {
+	if p.matches(name) {
+		return Redacted
+	}
+	return value
+}
+
+// applyGroup returns the value to log for a field that may be a group of
+// fields, such as an slog group. The fields of a group are matched by
+// their full path, joined with dots, such as "request.authorization".
+func (p *fieldPolicy) applyGroup(name string, value interface{}) interface{} {
+	if p.matches(name) {
+		return Redacted
+	}
+	var group, ok = value.(map[string]interface{})
+	if !ok {
+		return value
+	}
+	var applied = make(map[string]interface{}, len(group))
+	for key, child := range group {
+		applied[key] = p.applyGroup(name+"."+key, child)
+	}
+	return applied
+}
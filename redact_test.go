@@ -0,0 +1,98 @@
+package logevent
+
+import (
+	"bytes"
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+type eventSensitive struct {
+	Message string         `logevent:"message,default=sensitive"`
+	Token   string         `logevent:"token,redact"`
+	Email   string         `logevent:"email,hash"`
+	Card    string         `logevent:"card,mask=last4"`
+	Prefix  string         `logevent:"prefix,mask=first2"`
+	All     string         `logevent:"all,mask=everything"`
+	Missing string         `logevent:"missing,redact,default=fallback"`
+	Nested  EmbeddedStruct `logevent:"nested,redact"`
+	Plain   string         `logevent:"plain"`
+}
+
+func TestRedactionTags(t *testing.T) {
+	var r = &renderer{hashKey: []byte("salt")}
+	var _, annotations = r.render(eventSensitive{
+		Token:  "secret",
+		Email:  "user@example.com",
+		Card:   "4111111111111111",
+		Prefix: "abcdef",
+		All:    "abc",
+		Nested: EmbeddedStruct{One: "one"},
+		Plain:  "visible",
+	}, nil)
+	require.Equal(t, Redacted, annotations["token"])
+	require.Equal(t, hashValue([]byte("salt"), "user@example.com"), annotations["email"])
+	require.Len(t, annotations["email"], 64)
+	require.NotEqual(t, hashValue([]byte("other"), "user@example.com"), annotations["email"])
+	require.Equal(t, "************1111", annotations["card"])
+	require.Equal(t, "ab****", annotations["prefix"])
+	require.Equal(t, "***", annotations["all"])
+	require.Equal(t, Redacted, annotations["missing"])
+	require.Equal(t, Redacted, annotations["nested"])
+	require.Equal(t, "visible", annotations["plain"])
+}
+
+func TestMaskValue(t *testing.T) {
+	require.Equal(t, "***", maskValue(4, true, "abc"))
+	require.Equal(t, "**34", maskValue(2, true, 1234))
+	require.Equal(t, "", maskValue(4, true, ""))
+	require.Equal(t, "é**", maskValue(1, false, "éàü"))
+}
+
+func TestRedactionNil(t *testing.T) {
+	require.Nil(t, redaction{mode: redactFull}.apply(nil, nil))
+}
+
+func TestRedactFieldsPolicy(t *testing.T) {
+	var buff = &bytes.Buffer{}
+	var logger = New(Config{
+		Output:       buff,
+		RedactFields: []string{"authorization", "*_token", "["},
+	})
+	logger.SetField("Authorization", "Bearer abc")
+	logger.SetField("refresh_token", "abc")
+	logger.SetField("user", "alice")
+	logger.Copy().Info("hello")
+
+	var line = make(map[string]interface{})
+	require.Nil(t, json.Unmarshal(buff.Bytes(), &line))
+	require.Equal(t, Redacted, line["Authorization"])
+	require.Equal(t, Redacted, line["refresh_token"])
+	require.Equal(t, "alice", line["user"])
+}
+
+type eventSensitivePointers struct {
+	Message string  `logevent:"message,mask=first3"`
+	Card    *string `logevent:"card,mask=last4"`
+	Email   *string `logevent:"email,hash"`
+	Token   *string `logevent:"token,redact"`
+	Empty   string  `logevent:"empty,redact"`
+	Count   int     `logevent:"count,mask=last1"`
+}
+
+func TestRedactionPointers(t *testing.T) {
+	var r = &renderer{hashKey: []byte("salt")}
+	var card, email = "4111111111111111", "user@example.com"
+	var message, annotations = r.render(eventSensitivePointers{
+		Message: "secret message",
+		Card:    &card,
+		Email:   &email,
+	}, nil)
+	require.Equal(t, "sec***********", message)
+	require.Equal(t, "************1111", annotations["card"])
+	require.Equal(t, hashValue([]byte("salt"), email), annotations["email"])
+	require.Nil(t, annotations["token"])
+	require.Equal(t, "", annotations["empty"])
+	require.Equal(t, 0, annotations["count"])
+}
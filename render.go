@@ -3,141 +3,480 @@ package logevent
 import (
 	"fmt"
 	"reflect"
-	"strconv"
 	"strings"
 	"sync"
-
-	"github.com/fatih/structs"
 )
 
 const (
 	tagKey       = "logevent"
 	unknown      = "unknown"
 	defaultValue = "default="
+	messageField = "Message"
+)
+
+const (
+	// DefaultMaxDepth is the number of levels of nested structs that are
+	// rendered when Config.MaxDepth is not set.
+	DefaultMaxDepth = 32
+	// MaxDepthReached replaces a nested struct that is deeper than the
+	// depth limit.
+	MaxDepthReached = "[MAX DEPTH]"
+	// CycleDetected replaces a struct that refers back to one of the structs
+	// that contain it.
+	CycleDetected = "[CYCLE]"
+	// RenderErrorKey is the key name of the reason that an event could not
+	// be rendered.
+	RenderErrorKey = "render_error"
 )
 
-var mutex = &sync.RWMutex{}
-
-func getDefaultValue(f *structs.Field, value string) interface{} {
-	switch reflect.TypeOf(f.Value()).Kind() {
-	case reflect.String:
-		return value
-	case reflect.Bool:
-		var final, _ = strconv.ParseBool(value)
-		return final
-	case reflect.Int:
-		var final, _ = strconv.ParseInt(value, 10, strconv.IntSize)
-		return int(final)
-	case reflect.Int8:
-		var final, _ = strconv.ParseInt(value, 10, 8)
-		return int8(final)
-	case reflect.Int16:
-		var final, _ = strconv.ParseInt(value, 10, 16)
-		return int16(final)
-	case reflect.Int32:
-		var final, _ = strconv.ParseInt(value, 10, 32)
-		return int32(final)
-	case reflect.Int64:
-		var final, _ = strconv.ParseInt(value, 10, 64)
-		return final
-	case reflect.Float32:
-		var final, _ = strconv.ParseFloat(value, 32)
-		return float32(final)
-	case reflect.Float64:
-		var final, _ = strconv.ParseFloat(value, 64)
-		return final
+var stringType = reflect.TypeOf("")
+
+// defaultRenderer renders events for Render, which has no logger settings
+// to apply.
+var defaultRenderer = &renderer{}
+
+type fallbackEvent struct {
+	Message string `logevent:"message"`
+}
+
+// schemas caches the compiled schema of every event type that has been
+// rendered so that struct tags are only parsed once per type.
+var schemas = &sync.Map{}
+
+// schema is the compiled rendering plan for a struct type.
+type schema struct {
+	fields []fieldSchema
+	// message is the index path of the Message field, if any, which may be
+	// promoted from an embedded struct.
+	message    []int
+	hasMessage bool
+	messageDef string
+	// messageRedact is the redaction applied to the message.
+	messageRedact redaction
+}
+
+// fieldSchema is the compiled rendering plan for a single exported
+// struct field.
+type fieldSchema struct {
+	index int
+	name  string
+	// embedded is set for embedded structs and those tagged inline, whose
+	// fields are merged in to the parent.
+	embedded   bool
+	def        interface{}
+	hasDefault bool
+	redact     redaction
+	omitEmpty  bool
+	stringify  bool
+	flatten    bool
+}
+
+// value returns the value of the field, or the default value from the
+// tag if the field is zero valued.
+func (f *fieldSchema) value(v reflect.Value) interface{} {
+	if f.hasDefault && v.IsZero() {
+		return f.def
+	}
+	return v.Interface()
+}
+
+// omit reports whether the field is tagged omitempty and has an empty
+// value. Fields with a default are never empty.
+func (f *fieldSchema) omit(v reflect.Value) bool {
+	if !f.omitEmpty || f.hasDefault {
+		return false
+	}
+	switch v.Kind() {
+	case reflect.Array, reflect.Map, reflect.Slice, reflect.String:
+		return v.Len() == 0
 	default:
-		return f.Value()
+		return v.IsZero()
 	}
 }
 
-func getName(f *structs.Field) string {
-	var tags = strings.Split(f.Tag(tagKey), ",")
-	if len(tags) < 1 {
-		return f.Name()
+// tagOptions is the parsed form of a logevent struct tag.
+type tagOptions struct {
+	name       string
+	def        string
+	hasDefault bool
+	redact     redaction
+	omitEmpty  bool
+	inline     bool
+	stringify  bool
+	flatten    bool
+	// unknown lists the options that were not recognized.
+	unknown []string
+}
+
+func parseTag(tag string) tagOptions {
+	var tags = splitTag(tag)
+	var options = tagOptions{name: tags[0]}
+	for _, tag := range tags[1:] {
+		if tag == "" {
+			// an empty option, such as from a trailing comma, is ignored
+			continue
+		}
+		if def, ok := strings.CutPrefix(tag, defaultValue); ok {
+			options.def = def
+			options.hasDefault = true
+			continue
+		}
+		switch tag {
+		case "omitempty":
+			options.omitEmpty = true
+			continue
+		case "inline":
+			options.inline = true
+			continue
+		case "string":
+			options.stringify = true
+			continue
+		case "flatten":
+			options.flatten = true
+			continue
+		}
+		if r, ok, err := parseRedaction(tag); ok {
+			options.redact = r
+			if err != nil {
+				options.unknown = append(options.unknown, tag)
+			}
+			continue
+		}
+		options.unknown = append(options.unknown, tag)
 	}
-	return tags[0]
+	return options
 }
 
-func getValue(f *structs.Field) interface{} {
-	if !f.IsZero() {
-		return f.Value()
+// schemaOf returns the compiled schema of a struct type, compiling and
+// caching it if it has not been seen before.
+func schemaOf(t reflect.Type) *schema {
+	if s, ok := schemas.Load(t); ok {
+		return s.(*schema)
 	}
-	var tags = strings.Split(f.Tag(tagKey), ",")
-	for _, tag := range tags {
-		if strings.Contains(tag, defaultValue) {
-			var parts = strings.Split(tag, "=")
-			if len(parts) == 2 {
-				return getDefaultValue(f, parts[1])
-			}
+	var s, _ = schemas.LoadOrStore(t, compileSchema(t))
+	return s.(*schema)
+}
+
+func compileSchema(t reflect.Type) *schema {
+	var s = &schema{fields: make([]fieldSchema, 0, t.NumField())}
+	for x := 0; x < t.NumField(); x = x + 1 {
+		var field = t.Field(x)
+		if field.PkgPath != "" {
+			// unexported fields are silently omitted from the log
+			continue
+		}
+		var tag = field.Tag.Get(tagKey)
+		if tag == "-" {
+			continue
 		}
+		var options = parseTag(tag)
+		var f = fieldSchema{
+			index:     x,
+			name:      fieldName(field, options),
+			embedded:  field.Anonymous || options.inline,
+			redact:    options.redact,
+			omitEmpty: options.omitEmpty,
+			stringify: options.stringify,
+			flatten:   options.flatten,
+		}
+		if options.hasDefault {
+			var def, err = getDefaultValue(field.Type, options.def)
+			f.def = def
+			f.hasDefault = err == nil
+		}
+		s.fields = append(s.fields, f)
+	}
+	if field, ok := t.FieldByName(messageField); ok && field.Type == stringType {
+		s.message = field.Index
+		s.hasMessage = true
+		var options = parseTag(field.Tag.Get(tagKey))
+		s.messageDef = unescapeTag(options.def)
+		s.messageRedact = options.redact
 	}
-	return f.Value()
+	return s
+}
+
+// fieldName is the name of a field in the log. Fields without a name in
+// the tag use the name of the field, except for Message which is always
+// logged as the message.
+func fieldName(field reflect.StructField, options tagOptions) string {
+	if options.name != "" {
+		return options.name
+	}
+	if field.Name == messageField {
+		return "message"
+	}
+	return field.Name
 }
 
 // getMessage will render the value of the unknown const
 // if there is no Message field in the struct
-func getMessage(s *structs.Struct) string {
-	var message string
-	var msgField *structs.Field
-	var ok bool
-	msgField, ok = s.FieldOk("Message")
-	if !ok {
+func getMessage(v reflect.Value) string {
+	var s = schemaOf(v.Type())
+	if !s.hasMessage {
+		return unknown
+	}
+	var msgField, err = v.FieldByIndexErr(s.message)
+	if err != nil {
 		return unknown
 	}
-	message, ok = getValue(msgField).(string)
-	if ok && len(message) > 0 {
+	var message = msgField.String()
+	if len(message) > 0 {
 		return message
 	}
+	if len(s.messageDef) > 0 {
+		return s.messageDef
+	}
 	return unknown
 }
 
-func buildAnnotations(s *structs.Struct, annotations map[string]interface{}) {
+// redactMessage applies the redaction in the tag of the Message field, if
+// any, to the message of the struct.
+func (r *renderer) redactMessage(v reflect.Value, message string) string {
+	var s = schemaOf(v.Type())
+	if !s.hasMessage || s.messageRedact.mode == redactNone || message == unknown {
+		return message
+	}
+	return valueString(s.messageRedact.apply(r.hashKey, message))
+}
+
+// maxIndirection limits the pointers and interfaces followed to reach a
+// struct so that a pointer that refers to itself cannot loop forever.
+const maxIndirection = 16
+
+// visit identifies a struct reached through a pointer. The type is kept
+// because a struct and its first field share an address.
+type visit struct {
+	ptr uintptr
+	t   reflect.Type
+}
+
+// resolve follows interfaces and pointers on the way to a struct value.
+// The last pointer followed is returned so that cycles can be detected.
+// The third return is false if the value does not hold a struct.
+func resolve(v reflect.Value) (reflect.Value, visit, bool) {
+	var seen visit
+	for x := 0; v.Kind() == reflect.Interface || v.Kind() == reflect.Ptr; x = x + 1 {
+		if v.IsNil() || x == maxIndirection {
+			return v, seen, false
+		}
+		if v.Kind() == reflect.Ptr {
+			seen = visit{ptr: v.Pointer(), t: v.Type()}
+		}
+		v = v.Elem()
+	}
+	return v, seen, v.Kind() == reflect.Struct
+}
+
+// structValue resolves interfaces and pointers on the way to a struct
+// value. The second return is false if the value does not hold a struct.
+func structValue(v reflect.Value) (reflect.Value, bool) {
+	var resolved, _, ok = resolve(v)
+	return resolved, ok
+}
 
-	s.TagName = tagKey
-	var strucs = []*structs.Struct{s}
+// renderer converts events in to annotations using the settings of a
+// logger.
+type renderer struct {
+	// hashKey salts the values of fields tagged with the hash option.
+	hashKey []byte
+	// maxElements limits the elements rendered for a collection of structs.
+	maxElements int
+	// maxDepth limits the nesting of structs that are rendered.
+	maxDepth int
+}
+
+func (r *renderer) depthLimit() int {
+	if r.maxDepth <= 0 {
+		return DefaultMaxDepth
+	}
+	return r.maxDepth
+}
+
+// renderPass is the state of rendering a single event.
+type renderPass struct {
+	*renderer
+	// stack, which may be nil, is used for errors that carry no stack trace
+	// of their own.
+	stack []uintptr
+	// visiting holds the structs on the path to the one being rendered.
+	visiting map[visit]bool
+}
+
+// nested renders a struct that is nested depth levels within the event.
+// A marker is returned in place of the struct if it is beyond the depth
+// limit or if it is already being rendered further up the path.
+func (p *renderPass) nested(v reflect.Value, seen visit, depth int) interface{} {
+	if depth > p.depthLimit() {
+		return MaxDepthReached
+	}
+	if seen.ptr != 0 {
+		if p.visiting[seen] {
+			return CycleDetected
+		}
+		if p.visiting == nil {
+			p.visiting = make(map[visit]bool)
+		}
+		p.visiting[seen] = true
+		defer delete(p.visiting, seen)
+	}
+	var annotations = make(map[string]interface{})
+	p.buildAnnotations(v, annotations, depth)
+	return annotations
+}
+
+// buildAnnotations walks a struct value using the compiled schema for its
+// type. Fields of embedded structs are flattened in to the parent in
+// breadth first order so that shallower fields take precedence, mirroring
+// Go's own field promotion rules.
+func (p *renderPass) buildAnnotations(v reflect.Value, annotations map[string]interface{}, depth int) {
+	var strucs = []reflect.Value{v}
+	var embedded = make(map[visit]bool)
 	for len(strucs) > 0 {
-		var wg sync.WaitGroup
-		wg.Add(len(strucs[0].Fields()))
-		for _, field := range strucs[0].Fields() {
-			// we spin up go routines to best-effort add annotations, but
-			// catch panics if any attempt to get an unexported field occurs
-			go func(field *structs.Field, annotations map[string]interface{}) {
-				// if any panic occurs, we don't want to break the runtime caller, so recover
-				defer func() {
-					if err := recover(); err != nil {
-						fmt.Println(err)
-					}
-					wg.Done()
-				}()
-				if structs.IsStruct(field.Value()) {
-					var fieldStruct = structs.New(field.Value())
-					if field.IsEmbedded() {
-						strucs = append(strucs, fieldStruct)
-						return
-					}
-					var noExportedFields = len(fieldStruct.Map()) == 0
-					if noExportedFields {
-						addIfNotExists(annotations, getName(field), getValue(field))
-						return
-					}
-					var subAnnotations = make(map[string]interface{})
-					addIfNotExists(annotations, getName(field), subAnnotations)
-					buildAnnotations(fieldStruct, subAnnotations)
-				} else {
-					addIfNotExists(annotations, getName(field), getValue(field))
+		var current = strucs[0]
+		strucs = strucs[1:]
+		var s = schemaOf(current.Type())
+		for x := range s.fields {
+			var f = &s.fields[x]
+			var field = current.Field(f.index)
+			var fieldStruct, seen, ok = resolve(field)
+			if ok && f.embedded {
+				if seen.ptr == 0 || !embedded[seen] {
+					embedded[seen] = true
+					strucs = append(strucs, fieldStruct)
+				}
+				continue
+			}
+			if f.embedded && (field.Kind() == reflect.Ptr || field.Kind() == reflect.Interface) && field.IsNil() {
+				// a nil struct that would be merged in adds nothing
+				continue
+			}
+			if f.omit(field) {
+				continue
+			}
+			if marshaled, isMarshaled := marshalValue(field); isMarshaled {
+				if marshaled == nil {
+					addIfNotExists(annotations, f.name, nil)
+					continue
 				}
-			}(field, annotations)
+				field = reflect.ValueOf(marshaled)
+				fieldStruct, seen, ok = resolve(field)
+			}
+			if f.redact.mode != redactNone {
+				addIfNotExists(annotations, f.name, f.redact.apply(p.hashKey, f.value(field)))
+				continue
+			}
+			if f.stringify {
+				addIfNotExists(annotations, f.name, textValue(f.value(field)))
+				continue
+			}
+			if err, isError := errorValue(field); isError {
+				addIfNotExists(annotations, f.name, renderError(err, p.stack))
+				continue
+			}
+			if text, isText := textualValue(field); isText {
+				addIfNotExists(annotations, f.name, text)
+				continue
+			}
+			if collection, isCollection := collectionValue(field); isCollection {
+				var rendered, omitted = p.renderCollection(collection, depth+1)
+				addIfNotExists(annotations, f.name, rendered)
+				if omitted > 0 {
+					addIfNotExists(annotations, f.name+omittedSuffix, omitted)
+				}
+				continue
+			}
+			if !ok {
+				addIfNotExists(annotations, f.name, f.value(field))
+				continue
+			}
+			var noExportedFields = len(schemaOf(fieldStruct.Type()).fields) == 0
+			if noExportedFields {
+				addIfNotExists(annotations, f.name, f.value(field))
+				continue
+			}
+			if _, exists := annotations[f.name]; exists {
+				continue
+			}
+			var rendered = p.nested(fieldStruct, seen, depth+1)
+			var subAnnotations, isMap = rendered.(map[string]interface{})
+			if !f.flatten || !isMap {
+				annotations[f.name] = rendered
+				continue
+			}
+			var flattened = make(map[string]interface{}, len(subAnnotations))
+			flatten(flattened, f.name, ".", subAnnotations)
+			for key, value := range flattened {
+				addIfNotExists(annotations, key, value)
+			}
 		}
-		wg.Wait()
-		strucs = strucs[1:]
 	}
 }
 
+// render produces the message and annotations of an event. Values that
+// are not structs, or pointers to structs, have no annotations.
+func (r *renderer) render(event interface{}, stack []uintptr) (string, map[string]interface{}) {
+	var annotations = make(map[string]interface{})
+	var v, seen, ok = resolve(reflect.ValueOf(event))
+	if !ok {
+		return unknown, annotations
+	}
+	var p = &renderPass{renderer: r, stack: stack}
+	if seen.ptr != 0 {
+		p.visiting = map[visit]bool{seen: true}
+	}
+	p.buildAnnotations(v, annotations, 0)
+	var message = r.redactMessage(v, getMessage(v))
+	delete(annotations, "message")
+	return message, annotations
+}
+
+// event renders any value passed to a logging method. The stack, which may
+// be nil, is used for errors that carry no stack trace of their own. A
+// panic while rendering, such as from the Error method of an event, is
+// reported in the RenderErrorKey annotation.
+func (r *renderer) event(event interface{}, stack []uintptr) (message string, annotations map[string]interface{}) {
+	defer func() {
+		if recovered := recover(); recovered != nil {
+			message = unknown
+			annotations = map[string]interface{}{RenderErrorKey: fmt.Sprint(recovered)}
+		}
+	}()
+	if marshaled, ok := marshalValue(reflect.ValueOf(event)); ok {
+		event = marshaled
+	}
+	// Fallback for string values to unstructured logging. This exists to
+	// help with migration paths from unstructured to structured by allowing
+	// refactors to occur over time. It is **not** recommended to use this
+	// feature if the logs can be made into structs.
+	if event == nil {
+		event = "(nil)"
+	}
+	if msg, ok := event.(string); ok {
+		event = fallbackEvent{Message: msg}
+	}
+	message, annotations = r.render(event, stack)
+	if message == unknown {
+		// struct is lacking a Message field, or Message field is "".
+		// As a last resort, see if the event is error type
+		if err, ok := errorValue(reflect.ValueOf(event)); ok {
+			message = err.Error()
+			addIfNotExists(annotations, eventErrorKey, renderError(err, stack))
+		}
+	}
+	return message, annotations
+}
+
+// Render returns the message and annotations that a Logger emits for the
+// event, excluding any fields set on the Logger itself. It is intended for
+// tools, such as test recorders, that need to inspect events the same way
+// that a Logger would render them.
+func Render(event interface{}) (string, map[string]interface{}) {
+	return defaultRenderer.event(event, nil)
+}
+
 func addIfNotExists(m map[string]interface{}, key string, value interface{}) {
-	mutex.Lock()
 	if _, ok := m[key]; !ok {
 		m[key] = value
 	}
-	mutex.Unlock()
 }
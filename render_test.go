@@ -3,8 +3,9 @@ package logevent
 import (
 	"reflect"
 	"testing"
+	"time"
 
-	"github.com/fatih/structs"
+	"github.com/stretchr/testify/require"
 )
 
 type eventNoMessage struct{}
@@ -34,48 +35,42 @@ type eventMessage struct {
 }
 
 func TestLoggerEventNoMessage(t *testing.T) {
-	var s = structs.New(eventNoMessage{})
-	var result = getMessage(s)
+	var result = getMessage(reflect.ValueOf(eventNoMessage{}))
 	if result != unknown {
 		t.Fatalf("expected %s but got %s", unknown, result)
 	}
 }
 
 func TestLoggerEventWrongMessageType(t *testing.T) {
-	var s = structs.New(eventMessageWrongType{})
-	var result = getMessage(s)
+	var result = getMessage(reflect.ValueOf(eventMessageWrongType{}))
 	if result != unknown {
 		t.Fatalf("expected %s but got %s", unknown, result)
 	}
 }
 
 func TestLoggerEventExplicitMessage(t *testing.T) {
-	var s = structs.New(eventMessage{Message: "explicit"})
-	var result = getMessage(s)
+	var result = getMessage(reflect.ValueOf(eventMessage{Message: "explicit"}))
 	if result != "explicit" {
 		t.Fatalf("expected explicit but got %s", result)
 	}
 }
 
 func TestLoggerEventEmptyMessageNoDefault(t *testing.T) {
-	var s = structs.New(eventMessageAnnotationsNoDefault{})
-	var result = getMessage(s)
+	var result = getMessage(reflect.ValueOf(eventMessageAnnotationsNoDefault{}))
 	if result != unknown {
 		t.Fatalf("expected %s but got %s", unknown, result)
 	}
 }
 
 func TestLoggerEventEmptyMessageBadDefault(t *testing.T) {
-	var s = structs.New(eventMessageBadAnnotation{})
-	var result = getMessage(s)
+	var result = getMessage(reflect.ValueOf(eventMessageBadAnnotation{}))
 	if result != unknown {
 		t.Fatalf("expected %s but got %s", unknown, result)
 	}
 }
 
 func TestLoggerEventEmptyMessageDefault(t *testing.T) {
-	var s = structs.New(eventMessage{})
-	var result = getMessage(s)
+	var result = getMessage(reflect.ValueOf(eventMessage{}))
 	if result != "testvalue" {
 		t.Fatalf("expected testvalue but got %s", result)
 	}
@@ -87,12 +82,12 @@ type defaultValueTestCase struct {
 }
 
 func TestLoggerEventDefaultValues(t *testing.T) {
-	var s = structs.New(eventDefaultNumbers{})
-	var intResult = getValue(s.Field("Three")).(int)
+	var _, annotations = (&renderer{}).render(eventDefaultNumbers{}, nil)
+	var intResult = annotations["three"].(int)
 	if intResult != 12 {
 		t.Fatalf("expected 12 but got %d", intResult)
 	}
-	var floatResult = getValue(s.Field("Four")).(float64)
+	var floatResult = annotations["four"].(float64)
 	if floatResult != .5 {
 		t.Fatalf("expected .5 but got %f", floatResult)
 	}
@@ -115,8 +110,10 @@ func TestLoggerEventDefaultValues(t *testing.T) {
 	}
 	for _, testCase := range cases {
 		t.Run(reflect.TypeOf(testCase.TestValue).String(), func(tt *testing.T) {
-			var s = structs.New(testCase)
-			var result = getDefaultValue(s.Field("TestValue"), testCase.StringValue)
+			var result, err = getDefaultValue(reflect.TypeOf(testCase.TestValue), testCase.StringValue)
+			if err != nil {
+				tt.Fatal(err)
+			}
 			if reflect.TypeOf(result) != reflect.TypeOf(testCase.TestValue) {
 				tt.Errorf("failed to return correct type. instead got %s", reflect.TypeOf(result))
 			}
@@ -126,3 +123,188 @@ func TestLoggerEventDefaultValues(t *testing.T) {
 		})
 	}
 }
+
+func TestSchemaCached(t *testing.T) {
+	var first = schemaOf(reflect.TypeOf(eventMessage{}))
+	var second = schemaOf(reflect.TypeOf(eventMessage{}))
+	if first != second {
+		t.Fatal("expected the compiled schema to be cached")
+	}
+	if len(first.fields) != 3 {
+		t.Fatalf("expected 3 fields but got %d", len(first.fields))
+	}
+}
+
+type eventPromotedField struct {
+	EventWithEmbeddedStructs
+	Two string `logevent:"two,default=outer"`
+}
+
+func TestRenderEmbeddedPrecedence(t *testing.T) {
+	var message, annotations = (&renderer{}).render(&eventPromotedField{}, nil)
+	if message != "testvalue" {
+		t.Fatalf("expected testvalue but got %s", message)
+	}
+	if annotations["one"] != "fizz" {
+		t.Fatalf("expected the shallowest field to win but got %v", annotations["one"])
+	}
+	if annotations["two"] != "outer" {
+		t.Fatalf("expected the outer field to win but got %v", annotations["two"])
+	}
+	if _, ok := annotations["message"]; ok {
+		t.Fatal("expected message to be removed from the annotations")
+	}
+}
+
+func TestRenderNotStruct(t *testing.T) {
+	var message, annotations = (&renderer{}).render(42, nil)
+	if message != unknown || len(annotations) != 0 {
+		t.Fatalf("expected an empty render but got %s %v", message, annotations)
+	}
+}
+
+func TestRender(t *testing.T) {
+	var message, annotations = Render("plain")
+	if message != "plain" || len(annotations) != 0 {
+		t.Fatalf("expected a plain message but got %s %v", message, annotations)
+	}
+	message, _ = Render(nil)
+	if message != "(nil)" {
+		t.Fatalf("expected (nil) but got %s", message)
+	}
+	message, _ = Render(EventThatIsErrorType{})
+	if message != "hi I'm an error" {
+		t.Fatalf("expected the error message but got %s", message)
+	}
+	message, annotations = Render(eventMessage{Two: 2})
+	if message != "testvalue" || annotations["one"] != "foo" || annotations["two"] != 2 {
+		t.Fatalf("unexpected render %s %v", message, annotations)
+	}
+}
+
+type requestDetails struct {
+	Method  string            `logevent:"method"`
+	Headers map[string]string `logevent:"headers,omitempty"`
+}
+
+type InlinePointer struct {
+	Extra string `logevent:"extra"`
+}
+
+type eventTagOptions struct {
+	*InlinePointer
+	Untagged  string
+	Message   string
+	Skipped   string            `logevent:"-"`
+	Empty     string            `logevent:"empty,omitempty"`
+	EmptyMap  map[string]string `logevent:"empty_map,omitempty"`
+	Defaulted int               `logevent:"defaulted,omitempty,default=7"`
+	Inline    EmbeddedStruct    `logevent:"inline,inline"`
+	Duration  time.Duration     `logevent:"duration,string"`
+	Request   requestDetails    `logevent:"http.request,flatten"`
+	Kept      int               `logevent:"kept,omitempty"`
+	Pointer   *requestDetails   `logevent:"pointer,inline"`
+}
+
+func TestRenderTagOptions(t *testing.T) {
+	var message, annotations = Render(eventTagOptions{
+		Untagged: "value",
+		Message:  "tagless",
+		Skipped:  "hidden",
+		Inline:   EmbeddedStruct{One: "inlined", Two: timeField},
+		Duration: time.Second,
+		Request:  requestDetails{Method: "GET"},
+		Kept:     1,
+	})
+	require.Equal(t, "tagless", message)
+	require.Equal(t, map[string]interface{}{
+		"Untagged":            "value",
+		"defaulted":           7,
+		"one":                 "inlined",
+		"two":                 timeField,
+		"duration":            "1s",
+		"http.request.method": "GET",
+		"kept":                1,
+	}, annotations)
+
+	_, annotations = Render(eventTagOptions{
+		InlinePointer: &InlinePointer{Extra: "embedded"},
+		Pointer:       &requestDetails{Method: "PUT"},
+	})
+	require.Equal(t, "embedded", annotations["extra"])
+	require.Equal(t, "PUT", annotations["method"])
+}
+
+type node struct {
+	Name    string `logevent:"name"`
+	Next    *node  `logevent:"next"`
+	Message string `logevent:"message,default=node"`
+}
+
+type SelfEmbedded struct {
+	*SelfEmbedded
+	Value int `logevent:"value"`
+}
+
+type panicError struct{}
+
+func (*panicError) Error() string {
+	panic("broken error")
+}
+
+type eventWithPanic struct {
+	Err error `logevent:"err,string"`
+}
+
+func TestRenderIndirection(t *testing.T) {
+	var inner = &node{Name: "inner"}
+	var pointer = &inner
+	var _, annotations = Render(struct {
+		Any     interface{} `logevent:"any"`
+		Double  **node      `logevent:"double"`
+		Nil     *node       `logevent:"nil"`
+		Message string
+	}{Any: &node{Name: "any"}, Double: pointer})
+	require.Equal(t, map[string]interface{}{"name": "any", "next": (*node)(nil), "message": "node"}, annotations["any"])
+	require.Equal(t, "inner", annotations["double"].(map[string]interface{})["name"])
+	require.Nil(t, annotations["nil"])
+}
+
+func TestRenderCycles(t *testing.T) {
+	var first = &node{Name: "first"}
+	first.Next = &node{Name: "second", Next: first}
+	var _, annotations = Render(first)
+	require.Equal(t, CycleDetected, annotations["next"].(map[string]interface{})["next"])
+
+	var shared = &node{Name: "shared"}
+	_, annotations = Render(struct {
+		Left  *node `logevent:"left"`
+		Right *node `logevent:"right"`
+	}{Left: shared, Right: shared})
+	require.Equal(t, "shared", annotations["left"].(map[string]interface{})["name"])
+	require.Equal(t, "shared", annotations["right"].(map[string]interface{})["name"])
+
+	var self = &SelfEmbedded{Value: 1}
+	self.SelfEmbedded = self
+	_, annotations = Render(self)
+	require.Equal(t, 1, annotations["value"])
+}
+
+func TestRenderMaxDepth(t *testing.T) {
+	var _, annotations = (&renderer{maxDepth: 2}).render(buildEvent(5), nil)
+	var second = annotations["nested"].(map[string]interface{})["nested"].(map[string]interface{})
+	require.Equal(t, 2, second["value"])
+	require.Equal(t, MaxDepthReached, second["nested"])
+
+	_, annotations = Render(buildEvent(DefaultMaxDepth))
+	for depth := 1; depth < DefaultMaxDepth; depth = depth + 1 {
+		annotations = annotations["nested"].(map[string]interface{})
+	}
+	require.Equal(t, DefaultMaxDepth-1, annotations["value"])
+}
+
+func TestRenderPanic(t *testing.T) {
+	var message, annotations = Render(eventWithPanic{Err: &panicError{}})
+	require.Equal(t, unknown, message)
+	require.Equal(t, map[string]interface{}{RenderErrorKey: "broken error"}, annotations)
+}
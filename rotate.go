@@ -0,0 +1,316 @@
+package logevent
+
+import (
+	"compress/gzip"
+	"io"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+)
+
+const (
+	rotatedTimeFormat = "20060102T150405.000000000"
+	compressedSuffix  = ".gz"
+)
+
+// RotationConfig records the settings of a RotatingFile. A file is rotated
+// when either of MaxSize or Interval is reached.
+type RotationConfig struct {
+	// MaxSize is the size in bytes after which the file is rotated. Zero
+	// disables rotation by size.
+	MaxSize int64 `yaml:"max_size"`
+	// Interval rotates the file at wall clock boundaries, such as every
+	// hour, aligned to UTC. Zero disables rotation by time.
+	Interval time.Duration `yaml:"interval"`
+	// Compress gzips rotated files.
+	Compress bool `yaml:"compress"`
+	// MaxBackups is the number of rotated files kept. Zero keeps all of
+	// them.
+	MaxBackups int `yaml:"max_backups"`
+	// MaxAge is the age after which rotated files are removed. Zero keeps
+	// them forever.
+	MaxAge time.Duration `yaml:"max_age"`
+	// ReopenOnSIGHUP reopens the file when the process receives SIGHUP so
+	// that it may be rotated by an external tool such as logrotate.
+	ReopenOnSIGHUP bool `yaml:"reopen_on_sighup"`
+}
+
+// RotatingFile is an io.Writer that appends to a file and rotates it. It is
+// safe for concurrent use and may be used as Config.Output. Rotated files
+// are renamed with the UTC time of rotation, such as
+// app-20240102T150405.000000000.log for the file app.log.
+type RotatingFile struct {
+	path     string
+	c        RotationConfig
+	now      func() time.Time
+	lock     sync.Mutex
+	file     *os.File
+	closed   bool
+	size     int64
+	next     time.Time
+	mill     sync.WaitGroup
+	millLock sync.Mutex
+	signals  chan os.Signal
+	done     chan struct{}
+}
+
+// NewRotatingFile opens, or creates, the file at path for appending.
+func NewRotatingFile(path string, c RotationConfig) (*RotatingFile, error) {
+	var f = &RotatingFile{path: path, c: c, now: time.Now}
+	if err := f.open(); err != nil {
+		return nil, err
+	}
+	if c.ReopenOnSIGHUP {
+		f.signals = make(chan os.Signal, 1)
+		f.done = make(chan struct{})
+		signal.Notify(f.signals, syscall.SIGHUP)
+		go f.watch()
+	}
+	return f, nil
+}
+
+// Write appends to the file, rotating it first if it is due. If the
+// rotation fails then the data is still appended to the file and the error
+// from the rotation is returned.
+func (f *RotatingFile) Write(p []byte) (int, error) {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+	if err := f.ready(); err != nil {
+		return 0, err
+	}
+	var due = f.c.MaxSize > 0 && f.size > 0 && f.size+int64(len(p)) > f.c.MaxSize
+	if f.c.Interval > 0 && !f.now().Before(f.next) {
+		if f.size == 0 {
+			// there is nothing to rotate in to a backup
+			f.next = f.nextInterval()
+		} else {
+			due = true
+		}
+	}
+	var rotateErr error
+	if due {
+		rotateErr = f.rotate()
+		if f.file == nil {
+			return 0, rotateErr
+		}
+	}
+	var n, err = f.file.Write(p)
+	f.size = f.size + int64(n)
+	if err == nil {
+		err = rotateErr
+	}
+	return n, err
+}
+
+// Rotate rotates the file immediately.
+func (f *RotatingFile) Rotate() error {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+	if err := f.ready(); err != nil {
+		return err
+	}
+	return f.rotate()
+}
+
+// Reopen closes and reopens the file without renaming it. It is used after
+// the file has been moved by an external tool.
+func (f *RotatingFile) Reopen() error {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+	if f.closed {
+		return os.ErrClosed
+	}
+	if f.file != nil {
+		var err = f.file.Close()
+		f.file = nil
+		if err != nil {
+			return err
+		}
+	}
+	return f.open()
+}
+
+// Close closes the file and waits for any compression or removal of
+// rotated files to finish.
+func (f *RotatingFile) Close() error {
+	f.lock.Lock()
+	if f.closed {
+		f.lock.Unlock()
+		return nil
+	}
+	var err error
+	if f.file != nil {
+		err = f.file.Close()
+	}
+	f.file = nil
+	f.closed = true
+	if f.signals != nil {
+		signal.Stop(f.signals)
+		close(f.done)
+	}
+	f.lock.Unlock()
+	f.mill.Wait()
+	return err
+}
+
+// open opens the file at path. The lock must be held.
+func (f *RotatingFile) open() error {
+	if err := os.MkdirAll(filepath.Dir(f.path), 0755); err != nil {
+		return err
+	}
+	var file, err = os.OpenFile(f.path, os.O_WRONLY|os.O_APPEND|os.O_CREATE, 0644)
+	if err != nil {
+		return err
+	}
+	info, err := file.Stat()
+	if err != nil {
+		_ = file.Close()
+		return err
+	}
+	f.file = file
+	f.size = info.Size()
+	if f.c.Interval > 0 {
+		f.next = f.nextInterval()
+	}
+	return nil
+}
+
+// ready reopens the file if a previous rotation left it closed. The lock
+// must be held.
+func (f *RotatingFile) ready() error {
+	if f.closed {
+		return os.ErrClosed
+	}
+	if f.file == nil {
+		return f.open()
+	}
+	return nil
+}
+
+// nextInterval returns the start of the next rotation interval.
+func (f *RotatingFile) nextInterval() time.Time {
+	return f.now().UTC().Truncate(f.c.Interval).Add(f.c.Interval)
+}
+
+// rotate renames the current file, opens a new one, and then compresses
+// and prunes rotated files in the background. If the file cannot be
+// renamed then it is reopened so that writes continue to it. If it cannot
+// be opened then the next write tries again. The lock must be held.
+func (f *RotatingFile) rotate() error {
+	var err = f.file.Close()
+	f.file = nil
+	if err != nil {
+		return err
+	}
+	var rotated = f.rotatedName(f.now())
+	if err := os.Rename(f.path, rotated); err != nil && !os.IsNotExist(err) {
+		_ = f.open()
+		return err
+	}
+	if err := f.open(); err != nil {
+		return err
+	}
+	f.mill.Add(1)
+	go func() {
+		defer f.mill.Done()
+		f.millLock.Lock()
+		defer f.millLock.Unlock()
+		if f.c.Compress {
+			_ = compressFile(rotated)
+		}
+		f.prune()
+	}()
+	return nil
+}
+
+func (f *RotatingFile) rotatedName(t time.Time) string {
+	var ext = filepath.Ext(f.path)
+	var base = strings.TrimSuffix(f.path, ext)
+	return base + "-" + t.UTC().Format(rotatedTimeFormat) + ext
+}
+
+type backup struct {
+	name    string
+	rotated time.Time
+}
+
+// backups lists rotated files from oldest to newest.
+func (f *RotatingFile) backups() []backup {
+	var ext = filepath.Ext(f.path)
+	var prefix = filepath.Base(strings.TrimSuffix(f.path, ext)) + "-"
+	var entries, err = os.ReadDir(filepath.Dir(f.path))
+	if err != nil {
+		return nil
+	}
+	var found []backup
+	for _, entry := range entries {
+		var name = strings.TrimSuffix(entry.Name(), compressedSuffix)
+		if entry.IsDir() || !strings.HasPrefix(name, prefix) || !strings.HasSuffix(name, ext) {
+			continue
+		}
+		var stamp = strings.TrimSuffix(strings.TrimPrefix(name, prefix), ext)
+		var rotated, err = time.Parse(rotatedTimeFormat, stamp)
+		if err != nil {
+			continue
+		}
+		found = append(found, backup{name: entry.Name(), rotated: rotated})
+	}
+	sort.Slice(found, func(i int, j int) bool {
+		return found[i].rotated.Before(found[j].rotated)
+	})
+	return found
+}
+
+// prune removes rotated files beyond MaxBackups or older than MaxAge.
+func (f *RotatingFile) prune() {
+	var found = f.backups()
+	var cutoff = f.now().Add(-f.c.MaxAge)
+	for x, b := range found {
+		var remove = f.c.MaxBackups > 0 && x < len(found)-f.c.MaxBackups
+		remove = remove || (f.c.MaxAge > 0 && b.rotated.Before(cutoff))
+		if remove {
+			_ = os.Remove(filepath.Join(filepath.Dir(f.path), b.name))
+		}
+	}
+}
+
+func (f *RotatingFile) watch() {
+	for {
+		select {
+		case <-f.signals:
+			_ = f.Reopen()
+		case <-f.done:
+			return
+		}
+	}
+}
+
+// compressFile gzips the file and removes the original.
+func compressFile(path string) error {
+	var src, err = os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer src.Close()
+	dst, err := os.OpenFile(path+compressedSuffix, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0644)
+	if err != nil {
+		return err
+	}
+	var zw = gzip.NewWriter(dst)
+	if _, err = io.Copy(zw, src); err == nil {
+		err = zw.Close()
+	}
+	if closeErr := dst.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		_ = os.Remove(path + compressedSuffix)
+		return err
+	}
+	return os.Remove(path)
+}
@@ -0,0 +1,218 @@
+package logevent
+
+import (
+	"compress/gzip"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+// stepClock returns a clock that advances by a millisecond on every call
+// so that rotated files are never given the same name.
+func stepClock(start time.Time) func() time.Time {
+	var lock sync.Mutex
+	var now = start
+	return func() time.Time {
+		lock.Lock()
+		defer lock.Unlock()
+		now = now.Add(time.Millisecond)
+		return now
+	}
+}
+
+func readDir(t *testing.T, dir string) []string {
+	var entries, err = os.ReadDir(dir)
+	require.NoError(t, err)
+	var names []string
+	for _, entry := range entries {
+		names = append(names, entry.Name())
+	}
+	return names
+}
+
+func TestRotatingFileSize(t *testing.T) {
+	var dir = t.TempDir()
+	var path = filepath.Join(dir, "app.log")
+	var f, err = NewRotatingFile(path, RotationConfig{MaxSize: 10})
+	require.NoError(t, err)
+	f.now = stepClock(time.Date(2024, 1, 2, 15, 4, 5, 0, time.UTC))
+	for _, line := range []string{"12345\n", "67890\n", "abcde\n", "this line is too long\n"} {
+		var n, err = f.Write([]byte(line))
+		require.NoError(t, err)
+		require.Equal(t, len(line), n)
+	}
+	require.NoError(t, f.Close())
+
+	var names = readDir(t, dir)
+	require.Equal(t, []string{
+		"app-20240102T150405.001000000.log",
+		"app-20240102T150405.002000000.log",
+		"app-20240102T150405.003000000.log",
+		"app.log",
+	}, names)
+	var data, _ = os.ReadFile(path)
+	require.Equal(t, "this line is too long\n", string(data))
+	data, _ = os.ReadFile(filepath.Join(dir, names[0]))
+	require.Equal(t, "12345\n", string(data))
+
+	_, err = f.Write([]byte("closed"))
+	require.ErrorIs(t, err, os.ErrClosed)
+}
+
+func TestRotatingFileInterval(t *testing.T) {
+	var dir = t.TempDir()
+	var path = filepath.Join(dir, "app.log")
+	var now = time.Date(2024, 1, 2, 15, 59, 0, 0, time.UTC)
+	var f, err = NewRotatingFile(path, RotationConfig{Interval: time.Hour})
+	require.NoError(t, err)
+	f.now = func() time.Time { return now }
+	f.next = now.Truncate(time.Hour).Add(time.Hour)
+
+	_, _ = f.Write([]byte("before\n"))
+	now = now.Add(2 * time.Minute)
+	_, _ = f.Write([]byte("after\n"))
+	_, _ = f.Write([]byte("same hour\n"))
+	require.NoError(t, f.Close())
+
+	require.Equal(t, []string{"app-20240102T160100.000000000.log", "app.log"}, readDir(t, dir))
+	var data, _ = os.ReadFile(path)
+	require.Equal(t, "after\nsame hour\n", string(data))
+}
+
+func TestRotatingFileIntervalEmpty(t *testing.T) {
+	var dir = t.TempDir()
+	var path = filepath.Join(dir, "app.log")
+	var now = time.Date(2024, 1, 2, 15, 59, 0, 0, time.UTC)
+	var f, err = NewRotatingFile(path, RotationConfig{Interval: time.Hour})
+	require.NoError(t, err)
+	f.now = func() time.Time { return now }
+	f.next = now.Truncate(time.Hour).Add(time.Hour)
+
+	now = now.Add(2 * time.Minute)
+	_, _ = f.Write([]byte("first\n"))
+	_, _ = f.Write([]byte("same hour\n"))
+	require.NoError(t, f.Close())
+
+	require.Equal(t, []string{"app.log"}, readDir(t, dir))
+}
+
+func TestRotatingFileRotateFailure(t *testing.T) {
+	var dir = t.TempDir()
+	var path = filepath.Join(dir, "app.log")
+	var now = time.Date(2024, 1, 2, 15, 4, 5, 0, time.UTC)
+	var f, err = NewRotatingFile(path, RotationConfig{MaxSize: 10})
+	require.NoError(t, err)
+	f.now = func() time.Time { return now }
+	defer f.Close()
+
+	// a directory with the name of the backup cannot be replaced
+	var blocked = f.rotatedName(now)
+	require.NoError(t, os.MkdirAll(filepath.Join(blocked, "child"), 0755))
+	_, err = f.Write([]byte("12345\n"))
+	require.NoError(t, err)
+	n, err := f.Write([]byte("67890\n"))
+	require.Error(t, err)
+	require.Equal(t, 6, n)
+	var data, _ = os.ReadFile(path)
+	require.Equal(t, "12345\n67890\n", string(data))
+
+	// a file that failed to close is reopened by the next write
+	require.NoError(t, os.RemoveAll(blocked))
+	require.NoError(t, f.file.Close())
+	_, err = f.Write([]byte("lost\n"))
+	require.ErrorIs(t, err, os.ErrClosed)
+	require.Nil(t, f.file)
+	_, err = f.Write([]byte("abcde\n"))
+	require.NoError(t, err)
+	data, _ = os.ReadFile(path)
+	require.Equal(t, "abcde\n", string(data))
+	data, _ = os.ReadFile(blocked)
+	require.Equal(t, "12345\n67890\n", string(data))
+}
+
+func TestRotatingFileCompressAndPrune(t *testing.T) {
+	var dir = t.TempDir()
+	var path = filepath.Join(dir, "app.log")
+	var f, err = NewRotatingFile(path, RotationConfig{Compress: true, MaxBackups: 2})
+	require.NoError(t, err)
+	f.now = stepClock(time.Date(2024, 1, 2, 15, 4, 5, 0, time.UTC))
+	for x := 0; x < 4; x = x + 1 {
+		_, _ = fmt.Fprintf(f, "segment %d\n", x)
+		require.NoError(t, f.Rotate())
+	}
+	require.NoError(t, f.Close())
+
+	var names = readDir(t, dir)
+	require.Len(t, names, 3)
+	require.Equal(t, "app.log", names[2])
+	require.True(t, strings.HasSuffix(names[1], ".log.gz"))
+
+	var file, _ = os.Open(filepath.Join(dir, names[1]))
+	defer file.Close()
+	zr, err := gzip.NewReader(file)
+	require.NoError(t, err)
+	data, _ := io.ReadAll(zr)
+	require.Equal(t, "segment 3\n", string(data))
+}
+
+func TestRotatingFileMaxAge(t *testing.T) {
+	var dir = t.TempDir()
+	var path = filepath.Join(dir, "app.log")
+	var old = filepath.Join(dir, "app-20000101T000000.000000000.log")
+	require.NoError(t, os.WriteFile(old, []byte("old\n"), 0600))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "app-notes.log"), []byte("kept\n"), 0600))
+	var f, err = NewRotatingFile(path, RotationConfig{MaxAge: 24 * time.Hour})
+	require.NoError(t, err)
+	require.NoError(t, f.Rotate())
+	require.NoError(t, f.Close())
+
+	var names = readDir(t, dir)
+	require.Len(t, names, 3)
+	require.NotContains(t, names, filepath.Base(old))
+	require.Contains(t, names, "app-notes.log")
+}
+
+func TestRotatingFileConcurrent(t *testing.T) {
+	var dir = t.TempDir()
+	var f, err = NewRotatingFile(filepath.Join(dir, "app.log"), RotationConfig{MaxSize: 64})
+	require.NoError(t, err)
+	var logger = New(Config{Output: f})
+	var wg sync.WaitGroup
+	for x := 0; x < 4; x = x + 1 {
+		wg.Add(1)
+		go func(l Logger) {
+			defer wg.Done()
+			for y := 0; y < 20; y = y + 1 {
+				l.Info("concurrent")
+			}
+		}(logger.Copy())
+	}
+	wg.Wait()
+	require.NoError(t, f.Close())
+	var total int
+	for _, name := range readDir(t, dir) {
+		var data, _ = os.ReadFile(filepath.Join(dir, name))
+		total = total + strings.Count(string(data), "\n")
+	}
+	require.Equal(t, 80, total)
+}
+
+func TestParseConfigRotation(t *testing.T) {
+	var path = filepath.Join(t.TempDir(), "app.log")
+	var c, err = ParseConfig([]byte("output: " + path + "\nrotation: {max_size: 1048576, compress: true}\n"))
+	require.NoError(t, err)
+	var f, ok = c.Output.(*RotatingFile)
+	require.True(t, ok)
+	require.Equal(t, RotationConfig{MaxSize: 1048576, Compress: true}, f.c)
+	require.NoError(t, f.Close())
+
+	_, err = ParseConfig([]byte("rotation: {max_size: 10}"))
+	require.Error(t, err)
+}
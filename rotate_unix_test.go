@@ -0,0 +1,34 @@
+//go:build unix
+
+package logevent
+
+import (
+	"os"
+	"path/filepath"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestRotatingFileReopen(t *testing.T) {
+	var dir = t.TempDir()
+	var path = filepath.Join(dir, "app.log")
+	var f, err = NewRotatingFile(path, RotationConfig{ReopenOnSIGHUP: true})
+	require.NoError(t, err)
+	defer f.Close()
+	_, _ = f.Write([]byte("before\n"))
+	require.NoError(t, os.Rename(path, path+".1"))
+	require.NoError(t, syscall.Kill(os.Getpid(), syscall.SIGHUP))
+	require.Eventually(t, func() bool {
+		var _, err = os.Stat(path)
+		return err == nil
+	}, time.Second, 5*time.Millisecond)
+	_, _ = f.Write([]byte("after\n"))
+
+	var data, _ = os.ReadFile(path)
+	require.Equal(t, "after\n", string(data))
+	data, _ = os.ReadFile(path + ".1")
+	require.Equal(t, "before\n", string(data))
+}
@@ -0,0 +1,256 @@
+package logevent
+
+import (
+	"reflect"
+	"sync"
+	"time"
+
+	"github.com/rs/zerolog"
+)
+
+const defaultSamplingInterval = time.Second
+
+const (
+	// maxSampleKeys limits the number of level and event combinations that
+	// are counted separately. Events beyond the limit, such as messages
+	// that are formatted with changing values, share overflowSampleKey.
+	maxSampleKeys = 1000
+	// overflowSampleKey names the events counted once maxSampleKeys is
+	// reached.
+	overflowSampleKey = "(overflow)"
+)
+
+// SamplingPolicy limits how often an event is logged. Count based sampling
+// and rate limiting may be combined, in which case an event must pass both.
+type SamplingPolicy struct {
+	// Interval is the length of each sampling window. A SamplingSummary is
+	// logged at the end of any window in which events were suppressed.
+	// Defaults to one second.
+	Interval time.Duration `yaml:"interval"`
+	// First is the number of events logged in each window before
+	// Thereafter applies.
+	First int `yaml:"first"`
+	// Thereafter logs one in every Thereafter events once First events
+	// have been logged in the window. Zero suppresses all of them if First
+	// is set. Count based sampling is disabled if First and Thereafter are
+	// both zero.
+	Thereafter int `yaml:"thereafter"`
+	// Rate is the sustained number of events per second allowed by a token
+	// bucket. Zero disables rate limiting.
+	Rate float64 `yaml:"rate"`
+	// Burst is the size of the token bucket. Defaults to Rate, rounded up.
+	Burst int `yaml:"burst"`
+}
+
+// SamplingConfig records the sampling policies of a logger. Events are
+// counted separately for each combination of level and event type.
+type SamplingConfig struct {
+	// Levels maps level names, such as INFO, to the policy applied to all
+	// events at that level.
+	Levels map[string]SamplingPolicy `yaml:"levels"`
+	// Events maps event type names, as printed by the %T verb of fmt such
+	// as "mypkg.CacheMiss", to a policy that overrides the level policy.
+	// Pointers are keyed by the type to which they point. Events logged as
+	// plain strings are keyed by their message. Once 1000 different events
+	// are being counted, any others at the same level are counted together.
+	Events map[string]SamplingPolicy `yaml:"events"`
+}
+
+// SamplingSummary is the event logged at the end of a sampling window in
+// which events were suppressed. It is logged at the level of the
+// suppressed events.
+type SamplingSummary struct {
+	Event      string `logevent:"sampled_event"`
+	Suppressed uint64 `logevent:"suppressed"`
+	Message    string `logevent:"message,default=events-suppressed"`
+}
+
+type sampleKey struct {
+	level zerolog.Level
+	event string
+}
+
+// sampleCounter tracks the state of one level and event type.
+type sampleCounter struct {
+	policy     SamplingPolicy
+	start      time.Time
+	seen       int
+	suppressed uint64
+	scheduled  bool
+	tokens     float64
+	filled     time.Time
+}
+
+// sampler is shared by a logger and all of its copies.
+type sampler struct {
+	levels   map[zerolog.Level]SamplingPolicy
+	events   map[string]SamplingPolicy
+	report   func(level zerolog.Level, summary SamplingSummary)
+	now      func() time.Time
+	lock     sync.Mutex
+	counters map[sampleKey]*sampleCounter
+	// pruned is when counters were last checked for closed windows.
+	pruned time.Time
+}
+
+// newSampler returns nil if no policies are configured.
+func newSampler(c SamplingConfig, report func(zerolog.Level, SamplingSummary)) *sampler {
+	if len(c.Levels) == 0 && len(c.Events) == 0 {
+		return nil
+	}
+	var s = &sampler{
+		levels:   make(map[zerolog.Level]SamplingPolicy, len(c.Levels)),
+		events:   make(map[string]SamplingPolicy, len(c.Events)),
+		report:   report,
+		now:      time.Now,
+		counters: make(map[sampleKey]*sampleCounter),
+	}
+	s.pruned = s.now()
+	for name, policy := range c.Levels {
+		s.levels[levelFromString(name)] = normalizePolicy(policy)
+	}
+	for name, policy := range c.Events {
+		s.events[name] = normalizePolicy(policy)
+	}
+	return s
+}
+
+func normalizePolicy(p SamplingPolicy) SamplingPolicy {
+	if p.Interval <= 0 {
+		p.Interval = defaultSamplingInterval
+	}
+	if p.Rate > 0 && p.Burst < 1 {
+		p.Burst = int(p.Rate)
+		if float64(p.Burst) < p.Rate {
+			p.Burst = p.Burst + 1
+		}
+	}
+	return p
+}
+
+// sampleKeyOf names the event for the purposes of sampling.
+func sampleKeyOf(event interface{}) string {
+	if s, ok := event.(string); ok {
+		return s
+	}
+	var t = reflect.TypeOf(event)
+	if t == nil {
+		return ""
+	}
+	if t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	return t.String()
+}
+
+// allow reports whether the event should be logged.
+func (s *sampler) allow(level zerolog.Level, event interface{}) bool {
+	var name = sampleKeyOf(event)
+	var policy, ok = s.events[name]
+	if !ok {
+		policy, ok = s.levels[level]
+	}
+	if !ok {
+		return true
+	}
+	var key = sampleKey{level: level, event: name}
+	var now = s.now()
+
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	var c = s.counters[key]
+	if c == nil {
+		if now.Sub(s.pruned) >= defaultSamplingInterval {
+			s.prune(now)
+		}
+		if len(s.counters) >= maxSampleKeys {
+			key.event = overflowSampleKey
+			c = s.counters[key]
+		}
+	}
+	if c == nil {
+		c = &sampleCounter{policy: policy, start: now, tokens: float64(policy.Burst), filled: now}
+		s.counters[key] = c
+	}
+	if now.Sub(c.start) >= policy.Interval {
+		c.start = now
+		c.seen = 0
+	}
+	c.seen = c.seen + 1
+	if c.sampled() && c.takeToken(now) {
+		return true
+	}
+	c.suppressed = c.suppressed + 1
+	if !c.scheduled {
+		c.scheduled = true
+		time.AfterFunc(c.start.Add(policy.Interval).Sub(now), func() {
+			s.summarize(key)
+		})
+	}
+	return false
+}
+
+// prune removes the counters whose window has closed without a summary
+// pending and whose token bucket has refilled, as a new counter would
+// start in the same state.
+func (s *sampler) prune(now time.Time) {
+	s.pruned = now
+	for key, c := range s.counters {
+		if !c.scheduled && now.Sub(c.start) >= c.policy.Interval && c.refilled(now) {
+			delete(s.counters, key)
+		}
+	}
+}
+
+// refilled reports whether the token bucket would be full at now.
+func (c *sampleCounter) refilled(now time.Time) bool {
+	if c.policy.Rate <= 0 {
+		return true
+	}
+	return c.tokens+now.Sub(c.filled).Seconds()*c.policy.Rate >= float64(c.policy.Burst)
+}
+
+// sampled applies the first N then 1 in M policy.
+func (c *sampleCounter) sampled() bool {
+	if c.policy.First == 0 && c.policy.Thereafter == 0 {
+		return true
+	}
+	if c.seen <= c.policy.First {
+		return true
+	}
+	return c.policy.Thereafter > 0 && (c.seen-c.policy.First-1)%c.policy.Thereafter == 0
+}
+
+// takeToken applies the token bucket policy.
+func (c *sampleCounter) takeToken(now time.Time) bool {
+	if c.policy.Rate <= 0 {
+		return true
+	}
+	c.tokens = c.tokens + now.Sub(c.filled).Seconds()*c.policy.Rate
+	if c.tokens > float64(c.policy.Burst) {
+		c.tokens = float64(c.policy.Burst)
+	}
+	c.filled = now
+	if c.tokens < 1 {
+		return false
+	}
+	c.tokens = c.tokens - 1
+	return true
+}
+
+// summarize reports the events suppressed in the window that just ended.
+func (s *sampler) summarize(key sampleKey) {
+	s.lock.Lock()
+	var c = s.counters[key]
+	if c == nil {
+		s.lock.Unlock()
+		return
+	}
+	var suppressed = c.suppressed
+	c.suppressed = 0
+	c.scheduled = false
+	s.lock.Unlock()
+	if suppressed > 0 {
+		s.report(key.level, SamplingSummary{Event: key.event, Suppressed: suppressed})
+	}
+}
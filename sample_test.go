@@ -0,0 +1,166 @@
+package logevent
+
+import (
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/require"
+)
+
+type cacheMiss struct {
+	Message string `logevent:"message,default=cache-miss"`
+}
+
+type summaries struct {
+	lock  sync.Mutex
+	items []SamplingSummary
+	done  chan struct{}
+}
+
+func (s *summaries) report(level zerolog.Level, summary SamplingSummary) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	s.items = append(s.items, summary)
+	if s.done != nil {
+		close(s.done)
+		s.done = nil
+	}
+}
+
+type captureBackend struct {
+	lock  sync.Mutex
+	items []*record
+}
+
+func (b *captureBackend) write(r *record) {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+	b.items = append(b.items, r)
+}
+
+func (b *captureBackend) records() []*record {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+	return append([]*record(nil), b.items...)
+}
+
+// fakeClock returns a sampler clock that only moves when advanced.
+func fakeClock(s *sampler) func(time.Duration) {
+	var now = time.Now()
+	s.now = func() time.Time { return now }
+	return func(d time.Duration) { now = now.Add(d) }
+}
+
+func allowed(s *sampler, level zerolog.Level, event interface{}, n int) int {
+	var count int
+	for x := 0; x < n; x = x + 1 {
+		if s.allow(level, event) {
+			count = count + 1
+		}
+	}
+	return count
+}
+
+func TestSamplingFirstThereafter(t *testing.T) {
+	var s = newSampler(SamplingConfig{
+		Levels: map[string]SamplingPolicy{"INFO": {Interval: time.Hour, First: 3, Thereafter: 5}},
+	}, (&summaries{}).report)
+	fakeClock(s)
+	// 3 first, then the 4th, 9th, 14th, and 19th
+	require.Equal(t, 7, allowed(s, zerolog.InfoLevel, cacheMiss{}, 20))
+	// pointers share the count of the type to which they point
+	require.Equal(t, 1, allowed(s, zerolog.InfoLevel, &cacheMiss{}, 5))
+	// counted separately per event type and level
+	require.Equal(t, 3, allowed(s, zerolog.InfoLevel, "a message", 3))
+	require.Equal(t, 5, allowed(s, zerolog.WarnLevel, cacheMiss{}, 5))
+}
+
+func TestSamplingDistinctMessages(t *testing.T) {
+	var s = newSampler(SamplingConfig{
+		Levels: map[string]SamplingPolicy{"DEBUG": {Interval: time.Hour, First: 1}},
+	}, (&summaries{}).report)
+	var advance = fakeClock(s)
+	var count int
+	for x := 0; x < 3*maxSampleKeys; x = x + 1 {
+		if s.allow(zerolog.DebugLevel, fmt.Sprintf("user %d", x)) {
+			count = count + 1
+		}
+	}
+	// each message is counted alone until the limit, then all share one
+	require.Equal(t, maxSampleKeys+1, count)
+	require.Equal(t, maxSampleKeys+1, len(s.counters))
+	require.Equal(t, uint64(2*maxSampleKeys-1), s.counters[sampleKey{level: zerolog.DebugLevel, event: overflowSampleKey}].suppressed)
+
+	// counters are removed once their window has closed
+	advance(2 * time.Hour)
+	require.True(t, s.allow(zerolog.DebugLevel, "new user"))
+	// the overflow counter remains until its summary is reported
+	require.Equal(t, 2, len(s.counters))
+}
+
+func TestSamplingWindowReset(t *testing.T) {
+	var s = newSampler(SamplingConfig{
+		Events: map[string]SamplingPolicy{"logevent.cacheMiss": {Interval: time.Hour, First: 2}},
+	}, (&summaries{}).report)
+	var advance = fakeClock(s)
+	require.Equal(t, 2, allowed(s, zerolog.ErrorLevel, cacheMiss{}, 5))
+	advance(time.Hour)
+	require.Equal(t, 2, allowed(s, zerolog.ErrorLevel, cacheMiss{}, 5))
+	// other events are not sampled
+	require.Equal(t, 5, allowed(s, zerolog.ErrorLevel, "other", 5))
+}
+
+func TestSamplingRateLimit(t *testing.T) {
+	var s = newSampler(SamplingConfig{
+		Events: map[string]SamplingPolicy{"noisy": {Interval: time.Hour, Rate: 2, Burst: 3}},
+	}, (&summaries{}).report)
+	var advance = fakeClock(s)
+	require.Equal(t, 3, allowed(s, zerolog.InfoLevel, "noisy", 10))
+	advance(time.Second)
+	require.Equal(t, 2, allowed(s, zerolog.InfoLevel, "noisy", 10))
+	advance(time.Minute)
+	require.Equal(t, 3, allowed(s, zerolog.InfoLevel, "noisy", 10))
+}
+
+func TestSamplingSummary(t *testing.T) {
+	var done = make(chan struct{})
+	var reported = &summaries{done: done}
+	var s = newSampler(SamplingConfig{
+		Events: map[string]SamplingPolicy{"noisy": {Interval: 10 * time.Millisecond, First: 1}},
+	}, reported.report)
+	require.Equal(t, 1, allowed(s, zerolog.WarnLevel, "noisy", 4))
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("summary was not reported")
+	}
+	reported.lock.Lock()
+	defer reported.lock.Unlock()
+	require.Equal(t, []SamplingSummary{{Event: "noisy", Suppressed: 3}}, reported.items)
+}
+
+func TestSamplingLogger(t *testing.T) {
+	var b = &captureBackend{}
+	var logger = New(Config{Sampling: SamplingConfig{
+		Levels: map[string]SamplingPolicy{"DEBUG": {Interval: time.Hour, First: 1}},
+	}}).(*logger)
+	logger.backend = b
+	logger.Debug(cacheMiss{})
+	logger.Copy().Debug(cacheMiss{})
+	logger.Info(cacheMiss{})
+	require.Len(t, b.records(), 2)
+
+	logger.sampler.summarize(sampleKey{level: zerolog.DebugLevel, event: "logevent.cacheMiss"})
+	var records = b.records()
+	require.Len(t, records, 3)
+	require.Equal(t, "events-suppressed", records[2].message)
+	require.Equal(t, uint64(1), records[2].fields["suppressed"])
+	require.Equal(t, zerolog.DebugLevel, records[2].level)
+}
+
+func TestSamplingDisabled(t *testing.T) {
+	require.Nil(t, newSampler(SamplingConfig{}, nil))
+}
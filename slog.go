@@ -0,0 +1,228 @@
+package logevent
+
+import (
+	"context"
+	"log/slog"
+	"sort"
+
+	"github.com/rs/zerolog"
+)
+
+type slogBackend struct {
+	h slog.Handler
+}
+
+func (b *slogBackend) write(r *record) {
+	var ctx = context.Background()
+	var level = slogLevel(r.level)
+	if !b.h.Enabled(ctx, level) {
+		return
+	}
+	var rec = slog.NewRecord(r.time, level, r.message, r.pc)
+	rec.AddAttrs(attrsFromMap(r.fields)...)
+	_ = b.h.Handle(ctx, rec)
+}
+
+// attrsFromMap converts rendered annotations into slog attributes. Keys
+// are sorted so that handlers see a stable attribute order and nested
+// annotation maps become groups.
+func attrsFromMap(m map[string]interface{}) []slog.Attr {
+	var keys = make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	var attrs = make([]slog.Attr, 0, len(keys))
+	for _, key := range keys {
+		if nested, ok := m[key].(map[string]interface{}); ok {
+			attrs = append(attrs, slog.Attr{Key: key, Value: slog.GroupValue(attrsFromMap(nested)...)})
+			continue
+		}
+		attrs = append(attrs, slog.Any(key, m[key]))
+	}
+	return attrs
+}
+
+// slogLevel maps a logevent level on to the closest slog level.
+func slogLevel(level zerolog.Level) slog.Level {
+	switch level {
+	case zerolog.TraceLevel:
+		return slog.LevelDebug - 4
+	case zerolog.DebugLevel:
+		return slog.LevelDebug
+	case zerolog.InfoLevel:
+		return slog.LevelInfo
+	case zerolog.WarnLevel:
+		return slog.LevelWarn
+	case zerolog.ErrorLevel:
+		return slog.LevelError
+	case zerolog.FatalLevel:
+		return slog.LevelError + 4
+	case zerolog.PanicLevel:
+		return slog.LevelError + 8
+	default:
+		return slog.LevelInfo
+	}
+}
+
+// levelFromSlog maps an slog level on to the closest logevent level.
+func levelFromSlog(level slog.Level) zerolog.Level {
+	switch {
+	case level < slog.LevelDebug:
+		return zerolog.TraceLevel
+	case level < slog.LevelInfo:
+		return zerolog.DebugLevel
+	case level < slog.LevelWarn:
+		return zerolog.InfoLevel
+	case level < slog.LevelError:
+		return zerolog.WarnLevel
+	default:
+		return zerolog.ErrorLevel
+	}
+}
+
+// slogAttr is an attribute captured by WithAttrs along with the groups
+// that were open at the time.
+type slogAttr struct {
+	groups []string
+	attr   slog.Attr
+}
+
+type slogHandler struct {
+	logger Logger
+	attrs  []slogAttr
+	groups []string
+}
+
+// NewSlogHandler wraps a Logger in an slog.Handler so that libraries
+// logging through log/slog share the fields, transaction IDs, and output
+// of the Logger. Record attributes take precedence over fields set on the
+// Logger with SetField. Groups are rendered as nested annotations.
+func NewSlogHandler(logger Logger) slog.Handler {
+	return &slogHandler{logger: logger}
+}
+
+// Enabled reports whether the wrapped Logger emits events at the level.
+func (h *slogHandler) Enabled(_ context.Context, level slog.Level) bool {
+	if l, ok := h.logger.(*logger); ok {
+		return l.enabled(levelFromSlog(level))
+	}
+	return true
+}
+
+// Handle forwards the record to the wrapped Logger.
+func (h *slogHandler) Handle(_ context.Context, r slog.Record) error {
+	var annotations = make(map[string]interface{})
+	for _, a := range h.attrs {
+		addAttr(annotations, a.groups, a.attr)
+	}
+	r.Attrs(func(a slog.Attr) bool {
+		addAttr(annotations, h.groups, a)
+		return true
+	})
+	var level = levelFromSlog(r.Level)
+	if l, ok := h.logger.(*logger); ok {
+		// records are sampled by message, as for events logged as strings
+		if l.enabled(level) && (l.sampler == nil || l.sampler.allow(level, r.Message)) {
+			for key, value := range annotations {
+				annotations[key] = l.policy.applyGroup(key, value)
+			}
+			l.write(level, r.PC, r.Message, annotations)
+		}
+		return nil
+	}
+	var l = h.logger.Copy()
+	for key, value := range annotations {
+		l.SetField(key, value)
+	}
+	switch level {
+	case zerolog.TraceLevel:
+		l.Trace(r.Message)
+	case zerolog.DebugLevel:
+		l.Debug(r.Message)
+	case zerolog.InfoLevel:
+		l.Info(r.Message)
+	case zerolog.WarnLevel:
+		l.Warn(r.Message)
+	default:
+		l.Error(r.Message)
+	}
+	return nil
+}
+
+// WithAttrs returns a handler that includes the attributes in every
+// record.
+func (h *slogHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	var next = &slogHandler{
+		logger: h.logger,
+		attrs:  make([]slogAttr, 0, len(h.attrs)+len(attrs)),
+		groups: h.groups,
+	}
+	next.attrs = append(next.attrs, h.attrs...)
+	for _, a := range attrs {
+		next.attrs = append(next.attrs, slogAttr{groups: h.groups, attr: a})
+	}
+	return next
+}
+
+// WithGroup returns a handler that nests all following attributes under
+// the group name.
+func (h *slogHandler) WithGroup(name string) slog.Handler {
+	if name == "" {
+		return h
+	}
+	var groups = make([]string, 0, len(h.groups)+1)
+	groups = append(groups, h.groups...)
+	return &slogHandler{
+		logger: h.logger,
+		attrs:  h.attrs,
+		groups: append(groups, name),
+	}
+}
+
+// slogValue converts an slog value in to the form used for annotations.
+// Groups become maps.
+func slogValue(v slog.Value) interface{} {
+	v = v.Resolve()
+	if v.Kind() != slog.KindGroup {
+		return v.Any()
+	}
+	var annotations = make(map[string]interface{})
+	for _, a := range v.Group() {
+		addAttr(annotations, nil, a)
+	}
+	return annotations
+}
+
+// addAttr resolves an slog attribute and stores it in the annotations
+// under the given group path.
+func addAttr(annotations map[string]interface{}, groups []string, a slog.Attr) {
+	a.Value = a.Value.Resolve()
+	if a.Equal(slog.Attr{}) {
+		return
+	}
+	if a.Value.Kind() == slog.KindGroup {
+		var children = a.Value.Group()
+		if len(children) == 0 {
+			return
+		}
+		var path = groups
+		if a.Key != "" {
+			path = append(append(make([]string, 0, len(groups)+1), groups...), a.Key)
+		}
+		for _, child := range children {
+			addAttr(annotations, path, child)
+		}
+		return
+	}
+	var target = annotations
+	for _, group := range groups {
+		var next, ok = target[group].(map[string]interface{})
+		if !ok {
+			next = make(map[string]interface{})
+			target[group] = next
+		}
+		target = next
+	}
+	target[a.Key] = a.Value.Any()
+}
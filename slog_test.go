@@ -0,0 +1,146 @@
+package logevent
+
+import (
+	"bytes"
+	"encoding/json"
+	"log/slog"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSlogBackend(t *testing.T) {
+	var buff = &bytes.Buffer{}
+	var logger = New(Config{Handler: slog.NewJSONHandler(buff, nil)})
+	logger.SetField("out-of-event", "true")
+	logger.Warn(EventWithNestedStructs{Nested: EmbeddedStruct{One: "one"}})
+
+	var line = make(map[string]interface{})
+	require.Nil(t, json.Unmarshal(buff.Bytes(), &line))
+	require.Equal(t, "WARN", line["level"])
+	require.Equal(t, "testvalue", line["msg"])
+	require.Equal(t, "true", line["out-of-event"])
+	var nested = line["nested"].(map[string]interface{})
+	require.Equal(t, "one", nested["one"])
+	require.Equal(t, "testvalue", nested["message"])
+}
+
+func TestSlogBackendLevel(t *testing.T) {
+	var buff = &bytes.Buffer{}
+	var logger = New(Config{
+		Level:   "ERROR",
+		Handler: slog.NewJSONHandler(buff, nil),
+	})
+	logger.Info("filtered by logger")
+	require.Empty(t, buff.String())
+
+	logger = New(Config{Handler: slog.NewJSONHandler(buff, &slog.HandlerOptions{Level: slog.LevelWarn})})
+	logger.Info("filtered by handler")
+	require.Empty(t, buff.String())
+}
+
+func TestSlogHandler(t *testing.T) {
+	var buff = &bytes.Buffer{}
+	var logger = New(Config{Output: buff})
+	logger.SetField("out-of-event", "true")
+	logger.SetField("one", "overridden")
+	var sl = slog.New(NewSlogHandler(logger)).With("one", 1).WithGroup("group")
+	sl.Warn("hello", "two", "2", slog.Group("empty"))
+
+	var line = make(map[string]interface{})
+	require.Nil(t, json.Unmarshal(buff.Bytes(), &line))
+	require.Equal(t, "warn", line["level"])
+	require.Equal(t, "hello", line["message"])
+	require.Equal(t, "true", line["out-of-event"])
+	require.Equal(t, 1.0, line["one"])
+	require.True(t, strings.Contains(line["file"].(string), "slog_test.go"), line["file"])
+	var group = line["group"].(map[string]interface{})
+	require.Equal(t, "2", group["two"])
+	require.NotContains(t, group, "empty")
+}
+
+func TestSlogHandlerEnabled(t *testing.T) {
+	var buff = &bytes.Buffer{}
+	var logger = New(Config{Level: "WARN", Output: buff})
+	var sl = slog.New(NewSlogHandler(logger))
+	sl.Info("filtered")
+	require.Empty(t, buff.String())
+	sl.Error("kept")
+	require.Contains(t, buff.String(), "kept")
+}
+
+func TestSlogHandlerRedactGroups(t *testing.T) {
+	var buff = &bytes.Buffer{}
+	var logger = New(Config{Output: buff, RedactFields: []string{"request.authorization", "*.token", "secrets"}})
+	var sl = slog.New(NewSlogHandler(logger)).WithGroup("request")
+	sl.Info("hello", "authorization", "Bearer abc", "path", "/",
+		slog.Group("session", "token", "abc"), "token", "top")
+	slog.New(NewSlogHandler(logger)).Info("hello", slog.Group("secrets", "key", "abc"))
+
+	var lines = strings.Split(strings.TrimSpace(buff.String()), "\n")
+	require.Len(t, lines, 2)
+	var line = make(map[string]interface{})
+	require.Nil(t, json.Unmarshal([]byte(lines[0]), &line))
+	var request = line["request"].(map[string]interface{})
+	require.Equal(t, Redacted, request["authorization"])
+	require.Equal(t, Redacted, request["token"])
+	require.Equal(t, "/", request["path"])
+	require.Equal(t, Redacted, request["session"].(map[string]interface{})["token"])
+	line = make(map[string]interface{})
+	require.Nil(t, json.Unmarshal([]byte(lines[1]), &line))
+	require.Equal(t, Redacted, line["secrets"])
+}
+
+func TestSlogHandlerSampling(t *testing.T) {
+	var buff = &bytes.Buffer{}
+	var logger = New(Config{Output: buff, Sampling: SamplingConfig{
+		Levels: map[string]SamplingPolicy{"INFO": {Interval: time.Hour, First: 1}},
+	}})
+	var sl = slog.New(NewSlogHandler(logger))
+	for x := 0; x < 5; x = x + 1 {
+		sl.Info("noisy")
+	}
+	sl.Info("quiet")
+	var lines = strings.Split(strings.TrimSpace(buff.String()), "\n")
+	require.Len(t, lines, 2)
+	require.Contains(t, lines[0], "noisy")
+	require.Contains(t, lines[1], "quiet")
+}
+
+type fieldLogger struct {
+	Logger
+	fields map[string]interface{}
+	events []interface{}
+}
+
+func (l *fieldLogger) SetField(name string, value interface{}) {
+	l.fields[name] = value
+}
+
+func (l *fieldLogger) Copy() Logger {
+	return l
+}
+
+func (l *fieldLogger) Info(event interface{}) {
+	l.events = append(l.events, event)
+}
+
+func TestSlogHandlerGenericLogger(t *testing.T) {
+	var logger = &fieldLogger{fields: make(map[string]interface{})}
+	var sl = slog.New(NewSlogHandler(logger))
+	sl.Info("hello", "one", "1")
+	require.Equal(t, []interface{}{"hello"}, logger.events)
+	require.Equal(t, "1", logger.fields["one"])
+}
+
+func TestSlogLevels(t *testing.T) {
+	require.Equal(t, zerolog.TraceLevel, levelFromSlog(slog.LevelDebug-4))
+	require.Equal(t, zerolog.DebugLevel, levelFromSlog(slog.LevelDebug))
+	require.Equal(t, zerolog.ErrorLevel, levelFromSlog(slog.LevelError+8))
+	for _, level := range []zerolog.Level{zerolog.TraceLevel, zerolog.DebugLevel, zerolog.InfoLevel, zerolog.WarnLevel, zerolog.ErrorLevel} {
+		require.Equal(t, level, levelFromSlog(slogLevel(level)))
+	}
+}
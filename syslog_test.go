@@ -0,0 +1,163 @@
+package logevent
+
+import (
+	"bufio"
+	"bytes"
+	"io"
+	"net"
+	"path/filepath"
+	"runtime"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRFC5424Encoder(t *testing.T) {
+	var encode = rfc5424Encoder("web 1", "app", 42)
+	var buf = &bytes.Buffer{}
+	encode(buf, &record{
+		time:    time.Date(2024, 1, 2, 15, 4, 5, 123456789, time.UTC),
+		level:   zerolog.WarnLevel,
+		message: "slow request",
+		fields: map[string]interface{}{
+			"path":   `/a"b]\c`,
+			"nested": map[string]interface{}{"count": 3},
+			"a=b":    true,
+		},
+	})
+	require.Equal(t,
+		`<12>1 2024-01-02T15:04:05.123456Z web_1 app 42 - [fields@32473 a_b="true" nested.count="3" path="/a\"b\]\\c"] slow request`+"\n",
+		buf.String())
+
+	buf.Reset()
+	encode(buf, &record{level: zerolog.ErrorLevel})
+	require.Equal(t, "<11>1 - web_1 app 42 - -\n", buf.String())
+
+	buf.Reset()
+	encode(buf, &record{level: zerolog.FatalLevel})
+	require.Equal(t, "<10>1 - web_1 app 42 - -\n", buf.String())
+	buf.Reset()
+	encode(buf, &record{level: zerolog.PanicLevel})
+	require.Equal(t, "<9>1 - web_1 app 42 - -\n", buf.String())
+}
+
+func TestRFC5424EncoderCaller(t *testing.T) {
+	var encode = rfc5424Encoder("web", "app", 42)
+	var buf = &bytes.Buffer{}
+	var pcs = make([]uintptr, 1)
+	runtime.Callers(1, pcs)
+	var r = &record{level: zerolog.InfoLevel, pc: pcs[0], fields: map[string]interface{}{"file": "report.csv"}}
+	encode(buf, r)
+	require.Equal(t,
+		`<14>1 - web app 42 - [caller@32473 file="`+r.caller()+`"][fields@32473 file="report.csv"]`+"\n",
+		buf.String())
+}
+
+func TestSyslogWriterUDP(t *testing.T) {
+	var conn, err = net.ListenPacket("udp", "127.0.0.1:0")
+	require.NoError(t, err)
+	defer conn.Close()
+
+	w, err := NewSyslogWriter("udp", conn.LocalAddr().String())
+	require.NoError(t, err)
+	defer w.Close()
+	var logger = New(Config{Output: w, Encoder: EncoderRFC5424})
+	logger.Error("over udp")
+
+	var b = make([]byte, 2048)
+	_ = conn.SetReadDeadline(time.Now().Add(time.Second))
+	n, _, err := conn.ReadFrom(b)
+	require.NoError(t, err)
+	var message = string(b[:n])
+	require.True(t, strings.HasPrefix(message, "<11>1 "), message)
+	require.True(t, strings.HasSuffix(message, "] over udp"), message)
+}
+
+func TestSyslogWriterTCP(t *testing.T) {
+	var listener, err = net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	defer listener.Close()
+	var received = make(chan string, 2)
+	go func() {
+		var conn, err = listener.Accept()
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+		var reader = bufio.NewReader(conn)
+		for {
+			var length, err = readOctetCount(reader)
+			if err != nil {
+				return
+			}
+			var message = make([]byte, length)
+			if _, err := io.ReadFull(reader, message); err != nil {
+				return
+			}
+			received <- string(message)
+		}
+	}()
+
+	w, err := NewSyslogWriter("tcp", listener.Addr().String())
+	require.NoError(t, err)
+	defer w.Close()
+	var n int
+	n, err = w.Write([]byte("first\n"))
+	require.NoError(t, err)
+	require.Equal(t, 6, n)
+	_, err = w.Write([]byte("second message"))
+	require.NoError(t, err)
+	require.Equal(t, "first", <-received)
+	require.Equal(t, "second message", <-received)
+}
+
+func TestSyslogWriterUnixStream(t *testing.T) {
+	var path = filepath.Join(t.TempDir(), "log.sock")
+	var listener, err = net.Listen("unix", path)
+	require.NoError(t, err)
+	defer listener.Close()
+	var received = make(chan string, 2)
+	go func() {
+		var conn, err = listener.Accept()
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+		var reader = bufio.NewReader(conn)
+		for {
+			var line, err = reader.ReadString('\n')
+			if err != nil {
+				return
+			}
+			received <- line
+		}
+	}()
+
+	w, err := NewSyslogWriter("unix", path)
+	require.NoError(t, err)
+	defer w.Close()
+	_, err = w.Write([]byte("first line\nsecond line\n"))
+	require.NoError(t, err)
+	_, err = w.Write([]byte("next"))
+	require.NoError(t, err)
+	require.Equal(t, "first line\\nsecond line\n", <-received)
+	require.Equal(t, "next\n", <-received)
+}
+
+// readOctetCount reads the length that frames a message.
+func readOctetCount(r *bufio.Reader) (int, error) {
+	var prefix, err = r.ReadString(' ')
+	if err != nil {
+		return 0, err
+	}
+	return strconv.Atoi(strings.TrimSuffix(prefix, " "))
+}
+
+func TestSyslogWriterInvalid(t *testing.T) {
+	var _, err = NewSyslogWriter("tcp", "127.0.0.1:0")
+	require.Error(t, err)
+}
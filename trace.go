@@ -0,0 +1,71 @@
+package logevent
+
+import (
+	"context"
+	"encoding/hex"
+)
+
+const (
+	// TraceIDKey is the key name of the active trace id set in the logger.
+	TraceIDKey = "trace_id"
+	// SpanIDKey is the key name of the active span id set in the logger.
+	SpanIDKey = "span_id"
+	// TraceFlagsKey is the key name of the active trace flags set in the
+	// logger.
+	TraceFlagsKey = "trace_flags"
+
+	traceContextKey = ctxKey("__logevent_trace_context")
+)
+
+// TraceFlagSampled is the trace flag that marks a trace as sampled.
+const TraceFlagSampled byte = 0x01
+
+// TraceContext identifies the active trace and span using the W3C Trace
+// Context format so that logs may be correlated with distributed traces.
+type TraceContext struct {
+	// TraceID is the 32 character, lower case hex encoded trace id.
+	TraceID string
+	// SpanID is the 16 character, lower case hex encoded span id.
+	SpanID string
+	// TraceFlags are the W3C trace flags such as TraceFlagSampled.
+	TraceFlags byte
+	// TraceState is the opaque, vendor specific tracestate value.
+	TraceState string
+}
+
+// Sampled reports whether the sampled flag is set.
+func (tc TraceContext) Sampled() bool {
+	return tc.TraceFlags&TraceFlagSampled != 0
+}
+
+// Flags renders the trace flags as two hex characters.
+func (tc TraceContext) Flags() string {
+	return hex.EncodeToString([]byte{tc.TraceFlags})
+}
+
+// WithTraceContext returns a copy of the logger with the trace and span id
+// fields and a context that carries both the trace context and the new
+// logger. The given logger is not changed.
+func WithTraceContext(ctx context.Context, logger Logger, tc TraceContext) (context.Context, Logger) {
+	logger = logger.With(TraceIDKey, tc.TraceID, SpanIDKey, tc.SpanID, TraceFlagsKey, tc.Flags())
+	ctx = context.WithValue(ctx, traceContextKey, tc)
+	return NewContext(ctx, logger), logger
+}
+
+// SetTraceContext sets the trace and span ids in the logger and context.
+//
+// Deprecated: SetTraceContext changes the logger in place, which is seen by
+// every holder of the logger. Use WithTraceContext instead.
+func SetTraceContext(ctx context.Context, logger Logger, tc TraceContext) context.Context {
+	logger.SetField(TraceIDKey, tc.TraceID)
+	logger.SetField(SpanIDKey, tc.SpanID)
+	logger.SetField(TraceFlagsKey, tc.Flags())
+	return context.WithValue(ctx, traceContextKey, tc)
+}
+
+// GetTraceContext retrieves the trace context after `SetTraceContext` has
+// been called. The second return is false if no trace context has been set.
+func GetTraceContext(ctx context.Context) (TraceContext, bool) {
+	var tc, ok = ctx.Value(traceContextKey).(TraceContext)
+	return tc, ok
+}
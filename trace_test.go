@@ -0,0 +1,51 @@
+package logevent
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestSetAndGetTraceContext(t *testing.T) {
+	var _, ok = GetTraceContext(context.Background())
+	require.False(t, ok)
+
+	var buff = &bytes.Buffer{}
+	var logger = New(Config{Output: buff})
+	var tc = TraceContext{
+		TraceID:    "4bf92f3577b34da6a3ce929d0e0e4736",
+		SpanID:     "00f067aa0ba902b7",
+		TraceFlags: TraceFlagSampled,
+	}
+	var ctx = SetTraceContext(NewContext(context.Background(), logger), logger, tc)
+	var actual, found = GetTraceContext(ctx)
+	require.True(t, found)
+	require.Equal(t, tc, actual)
+	require.True(t, actual.Sampled())
+	require.False(t, TraceContext{}.Sampled())
+
+	FromContext(ctx).Info("traced")
+	var line = make(map[string]interface{})
+	require.Nil(t, json.Unmarshal(buff.Bytes(), &line))
+	require.Equal(t, tc.TraceID, line[TraceIDKey])
+	require.Equal(t, tc.SpanID, line[SpanIDKey])
+	require.Equal(t, "01", line[TraceFlagsKey])
+}
+
+func TestWithTraceContext(t *testing.T) {
+	var buff = &bytes.Buffer{}
+	var logger = New(Config{Output: buff})
+	var tc = TraceContext{TraceID: "4bf92f3577b34da6a3ce929d0e0e4736", SpanID: "00f067aa0ba902b7"}
+	var ctx, child = WithTraceContext(context.Background(), logger, tc)
+	var actual, _ = GetTraceContext(ctx)
+	require.Equal(t, tc, actual)
+
+	logger.Info("untraced")
+	require.NotContains(t, buff.String(), tc.TraceID)
+	FromContext(ctx).Info("traced")
+	require.Contains(t, buff.String(), tc.TraceID)
+	require.Equal(t, child, FromContext(ctx))
+}
@@ -15,21 +15,35 @@ const (
 
 // SetTransactionID sets a transaction id string in the logger and context.
 // If an empty string is passed in, then a randomly generated uuid will be used as the transaction id.
+//
+// Deprecated: SetTransactionID changes the logger in place, which is seen by
+// every holder of the logger. Use WithTransactionID instead.
 func SetTransactionID(ctx context.Context, logger *Logger, transactionID string) context.Context {
+	transactionID = resolveTransactionID(ctx, transactionID)
+	(*logger).SetField(TransactionIDKey, transactionID)
+	return context.WithValue(ctx, transactionIDContextKey, transactionID)
+}
 
-	if transactionID == "" {
-
-		var ok bool
-		transactionID, ok = ctx.Value(transactionIDContextKey).(string)
-		if !ok {
-			transactionID = uuid.New().String()
-		}
+// WithTransactionID returns a copy of the logger with the transaction id
+// field and a context that carries both the transaction id and the new
+// logger. The given logger is not changed. If an empty string is passed in
+// then the transaction id of the context is used, or a random uuid if the
+// context has none.
+func WithTransactionID(ctx context.Context, logger Logger, transactionID string) (context.Context, Logger) {
+	transactionID = resolveTransactionID(ctx, transactionID)
+	logger = logger.With(TransactionIDKey, transactionID)
+	ctx = context.WithValue(ctx, transactionIDContextKey, transactionID)
+	return NewContext(ctx, logger), logger
+}
 
+func resolveTransactionID(ctx context.Context, transactionID string) string {
+	if transactionID != "" {
+		return transactionID
 	}
-
-	(*logger).SetField(TransactionIDKey, transactionID)
-
-	return context.WithValue(ctx, transactionIDContextKey, transactionID)
+	if existing, ok := ctx.Value(transactionIDContextKey).(string); ok {
+		return existing
+	}
+	return uuid.New().String()
 }
 
 // GetTransactionID retrieves the transaction id after `SetTransactionID` has been called.
@@ -59,3 +59,21 @@ func TestSetAndGetTransactionID(t *testing.T) {
 		})
 	}
 }
+
+func TestWithTransactionID(t *testing.T) {
+	buf := &bytes.Buffer{}
+	logger := New(Config{Output: buf})
+	ctx, child := WithTransactionID(context.Background(), logger, "abcd")
+	assert.Equal(t, "abcd", GetTransactionID(ctx))
+	assert.Equal(t, child, FromContext(ctx))
+
+	logger.Info("parent statement")
+	assert.NotContains(t, buf.String(), "abcd")
+	child.Info("child statement")
+	assert.Contains(t, buf.String(), `"transaction_id":"abcd"`)
+
+	ctx, _ = WithTransactionID(ctx, logger, "")
+	assert.Equal(t, "abcd", GetTransactionID(ctx))
+	ctx, _ = WithTransactionID(context.Background(), logger, "")
+	assert.NotEmpty(t, GetTransactionID(ctx))
+}